@@ -2,7 +2,9 @@ package rtnl
 
 import (
 	"encoding/binary"
+	"fmt"
 	"net"
+	"strings"
 
 	"github.com/mdlayher/netlink"
 	log "github.com/sirupsen/logrus"
@@ -31,8 +33,38 @@ type AddrInfo struct {
 	Label     string
 	Broadcast net.IP
 	Anycast   net.IP
+
+	// Flags that may be requested when adding or replacing an address.
+	Nodad          bool
+	NoPrefixRoute  bool
+	ManageTempAddr bool
+	HomeAddress    bool
+
+	// Address state reported by the kernel, ignored when writing.
+	Tentative  bool
+	Deprecated bool
+	DadFailed  bool
+
+	// Lifetimes of the address, the kernel treats the address as permanent
+	// when not set.
+	CacheInfo *AddrCacheInfo
 }
 
+// AddrCacheInfo holds the lifetimes of an address in seconds, and its creation
+// and update timestamps in hundredths of a second since boot. Only the
+// lifetimes are considered when writing.
+type AddrCacheInfo struct {
+	Preferred uint32
+	Valid     uint32
+	Created   uint32
+	Updated   uint32
+}
+
+const (
+	// INFINITY_LIFE_TIME marks an address lifetime that never expires.
+	INFINITY_LIFE_TIME uint32 = 0xffffffff
+)
+
 // Return the address family, one of
 //   - AF_UNSPEC
 //   - AF_INET
@@ -71,40 +103,51 @@ func (a Address) Marshal() ([]byte, error) {
 	index := make([]byte, 4)
 	binary.LittleEndian.PutUint32(index, a.Msg.Index)
 
+	flags := uint32(a.Msg.Flags)
+	if a.Info != nil {
+		flags = flags&^addrSettableFlags | a.Info.flags()
+	}
+
 	buf := []byte{
 		a.Family(),
 		a.Prefix(),
-		a.Msg.Flags,
+		uint8(flags),
 		a.Msg.Scope,
 		index[0], index[1], index[2], index[3],
 	}
 
 	ae := netlink.NewAttributeEncoder()
 
+	// attributes are emitted in the same order the kernel uses
 	if a.Info != nil {
 		if a.Info.Address != nil {
-
-			ae.Bytes(unix.IFA_ADDRESS, a.Info.Address.IP)
-
-			if a.Info.Local == nil {
-				ae.Bytes(unix.IFA_LOCAL, a.Info.Address.IP)
-			}
-
+			ae.Bytes(unix.IFA_ADDRESS, ipBytes(a.Info.Address.IP))
 		}
 		if a.Info.Local != nil {
-			ae.String(unix.IFA_LOCAL, a.Info.Local.String())
-		}
-		if a.Info.Label != "" {
-			ae.String(unix.IFA_LABEL, a.Info.Label)
+			ae.Bytes(unix.IFA_LOCAL, ipBytes(a.Info.Local))
+		} else if a.Info.Address != nil {
+			ae.Bytes(unix.IFA_LOCAL, ipBytes(a.Info.Address.IP))
 		}
 		if a.Info.Broadcast != nil {
-			ae.String(unix.IFA_BROADCAST, a.Info.Broadcast.String())
+			ae.Bytes(unix.IFA_BROADCAST, ipBytes(a.Info.Broadcast))
 		}
 		if a.Info.Anycast != nil {
-			ae.String(unix.IFA_ANYCAST, a.Info.Anycast.String())
+			ae.Bytes(unix.IFA_ANYCAST, ipBytes(a.Info.Anycast))
+		}
+		if a.Info.Label != "" {
+			ae.String(unix.IFA_LABEL, a.Info.Label)
 		}
 	}
 
+	// flags beyond the first 8 bits only fit in the IFA_FLAGS attribute
+	if flags != 0 {
+		ae.Uint32(unix.IFA_FLAGS, flags)
+	}
+
+	if a.Info != nil && a.Info.CacheInfo != nil {
+		ae.Bytes(unix.IFA_CACHEINFO, a.Info.CacheInfo.Marshal())
+	}
+
 	attrs, err := ae.Encode()
 	if err != nil {
 		log.WithError(err).Error("failed to encode address attributes")
@@ -137,6 +180,8 @@ func (a *Address) Unmarshal(buf []byte) error {
 		return err
 	}
 
+	flags := uint32(a.Msg.Flags)
+
 	for ad.Next() {
 		switch ad.Type() {
 
@@ -152,20 +197,93 @@ func (a *Address) Unmarshal(buf []byte) error {
 			}
 
 		case unix.IFA_LOCAL:
-			a.Info.Local = net.ParseIP(ad.String())
+			a.Info.Local = net.IP(ad.Bytes())
 
 		case unix.IFA_LABEL:
 			a.Info.Label = ad.String()
 
 		case unix.IFA_BROADCAST:
-			a.Info.Broadcast = net.ParseIP(ad.String())
+			a.Info.Broadcast = net.IP(ad.Bytes())
 
 		case unix.IFA_ANYCAST:
-			a.Info.Anycast = net.ParseIP(ad.String())
+			a.Info.Anycast = net.IP(ad.Bytes())
+
+		case unix.IFA_FLAGS:
+			flags = ad.Uint32()
+
+		case unix.IFA_CACHEINFO:
+			a.Info.CacheInfo = &AddrCacheInfo{}
+			err := a.Info.CacheInfo.Unmarshal(ad.Bytes())
+			if err != nil {
+				return err
+			}
 
 		}
 	}
 
+	a.Info.setFlags(flags)
+
+	return nil
+
+}
+
+const addrSettableFlags = unix.IFA_F_NODAD | unix.IFA_F_NOPREFIXROUTE |
+	unix.IFA_F_MANAGETEMPADDR | unix.IFA_F_HOMEADDRESS
+
+func (ai *AddrInfo) flags() uint32 {
+
+	var flags uint32
+	if ai.Nodad {
+		flags |= unix.IFA_F_NODAD
+	}
+	if ai.NoPrefixRoute {
+		flags |= unix.IFA_F_NOPREFIXROUTE
+	}
+	if ai.ManageTempAddr {
+		flags |= unix.IFA_F_MANAGETEMPADDR
+	}
+	if ai.HomeAddress {
+		flags |= unix.IFA_F_HOMEADDRESS
+	}
+	return flags
+
+}
+
+func (ai *AddrInfo) setFlags(flags uint32) {
+
+	ai.Nodad = flags&unix.IFA_F_NODAD != 0
+	ai.NoPrefixRoute = flags&unix.IFA_F_NOPREFIXROUTE != 0
+	ai.ManageTempAddr = flags&unix.IFA_F_MANAGETEMPADDR != 0
+	ai.HomeAddress = flags&unix.IFA_F_HOMEADDRESS != 0
+	ai.Tentative = flags&unix.IFA_F_TENTATIVE != 0
+	ai.Deprecated = flags&unix.IFA_F_DEPRECATED != 0
+	ai.DadFailed = flags&unix.IFA_F_DADFAILED != 0
+
+}
+
+// Marshal turns address cache info into a struct ifa_cacheinfo.
+func (c AddrCacheInfo) Marshal() []byte {
+
+	buf := make([]byte, 16)
+	binary.LittleEndian.PutUint32(buf[0:4], c.Preferred)
+	binary.LittleEndian.PutUint32(buf[4:8], c.Valid)
+	binary.LittleEndian.PutUint32(buf[8:12], c.Created)
+	binary.LittleEndian.PutUint32(buf[12:16], c.Updated)
+	return buf
+
+}
+
+// Unmarshal reads address cache info from a struct ifa_cacheinfo.
+func (c *AddrCacheInfo) Unmarshal(buf []byte) error {
+
+	if len(buf) < 16 {
+		return fmt.Errorf("short address cache info")
+	}
+
+	c.Preferred = binary.LittleEndian.Uint32(buf[0:4])
+	c.Valid = binary.LittleEndian.Uint32(buf[4:8])
+	c.Created = binary.LittleEndian.Uint32(buf[8:12])
+	c.Updated = binary.LittleEndian.Uint32(buf[12:16])
 	return nil
 
 }
@@ -238,6 +356,44 @@ func AddAddr(ctx *Context, addr *Address) error {
 // AddAddrs adds the specified addresses.
 func AddAddrs(ctx *Context, addrs []*Address) error {
 
+	return modifyAddrs(ctx, unix.RTM_NEWADDR, netlink.Create|netlink.Append, addrs)
+
+}
+
+// ReplaceAddr adds the specified address, or updates it in place if it
+// already exists.
+func ReplaceAddr(ctx *Context, addr *Address) error {
+
+	return ReplaceAddrs(ctx, []*Address{addr})
+
+}
+
+// ReplaceAddrs adds the specified addresses, or updates them in place if they
+// already exist.
+func ReplaceAddrs(ctx *Context, addrs []*Address) error {
+
+	return modifyAddrs(ctx, unix.RTM_NEWADDR, netlink.Create|netlink.Replace, addrs)
+
+}
+
+// DelAddr removes the specified address.
+func DelAddr(ctx *Context, addr *Address) error {
+
+	return DelAddrs(ctx, []*Address{addr})
+
+}
+
+// DelAddrs removes the specified addresses.
+func DelAddrs(ctx *Context, addrs []*Address) error {
+
+	return modifyAddrs(ctx, unix.RTM_DELADDR, 0, addrs)
+
+}
+
+func modifyAddrs(
+	ctx *Context, op uint16, flags netlink.HeaderFlags, addrs []*Address,
+) error {
+
 	var messages []netlink.Message
 
 	for _, addr := range addrs {
@@ -250,11 +406,8 @@ func AddAddrs(ctx *Context, addrs []*Address) error {
 
 		m := netlink.Message{
 			Header: netlink.Header{
-				Type: unix.RTM_NEWADDR,
-				Flags: netlink.Request |
-					netlink.Acknowledge |
-					netlink.Create |
-					netlink.Append,
+				Type:  netlink.HeaderType(op),
+				Flags: netlink.Request | netlink.Acknowledge | flags,
 			},
 			Data: data,
 		}
@@ -267,6 +420,53 @@ func AddAddrs(ctx *Context, addrs []*Address) error {
 
 }
 
+// Methods ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// Add the address to the kernel.
+func (a *Address) Add(ctx *Context) error {
+
+	return AddAddr(ctx, a)
+
+}
+
+// Replace adds the address, or updates it in place if it already exists.
+func (a *Address) Replace(ctx *Context) error {
+
+	return ReplaceAddr(ctx, a)
+
+}
+
+// Del removes the address from the kernel.
+func (a *Address) Del(ctx *Context) error {
+
+	return DelAddr(ctx, a)
+
+}
+
+// Present ensures the address is present.
+func (a *Address) Present(ctx *Context) error {
+
+	err := a.Add(ctx)
+	if err != nil && !strings.Contains(err.Error(), "file exists") {
+		return err
+	}
+
+	return nil
+
+}
+
+// Absent ensures the address is absent.
+func (a *Address) Absent(ctx *Context) error {
+
+	err := a.Del(ctx)
+	if err != nil && !strings.Contains(err.Error(), "cannot assign requested address") {
+		return err
+	}
+
+	return nil
+
+}
+
 func ParseAddr(addr string) (*Address, error) {
 
 	ip, ipaddr, err := net.ParseCIDR(addr)
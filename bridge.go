@@ -1,7 +1,13 @@
 package rtnl
 
 import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
 	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
@@ -56,6 +62,13 @@ const (
 	IFLA_BRIDGE_VLAN_TUNNEL_INFO
 )
 
+const (
+	IFLA_BRIDGE_VLAN_TUNNEL_UNSPEC = iota
+	IFLA_BRIDGE_VLAN_TUNNEL_ID
+	IFLA_BRIDGE_VLAN_TUNNEL_VID
+	IFLA_BRIDGE_VLAN_TUNNEL_FLAGS
+)
+
 const (
 	BRIDGE_VLAN_INFO_MASTER = 1 << iota
 	BRIDGE_VLAN_INFO_PVID
@@ -70,8 +83,70 @@ const (
 	BRIDGE_FLAGS_SELF   = 2
 )
 
+// Bridge encapsulates information about bridge devices. Time values are in
+// hundredths of a second (USER_HZ) as the kernel reports them. Settings are
+// pointers and are only sent to the kernel when set, so that each may also be
+// set to its zero value.
 type Bridge struct {
 	VlanAware bool
+
+	// spanning tree properties
+	StpState     *uint32
+	ForwardDelay *uint32
+	HelloTime    *uint32
+	MaxAge       *uint32
+	AgeingTime   *uint32
+	Priority     *uint16
+
+	// vlan properties
+	VlanProtocol *uint16
+	DefaultPvid  *uint16
+	GroupFwdMask *uint16
+
+	// multicast properties
+	McastRouter             *uint8
+	McastSnooping           *bool
+	McastQueryUseIfaddr     *bool
+	McastQuerier            *bool
+	McastHashElasticity     *uint32
+	McastHashMax            *uint32
+	McastLastMemberCnt      *uint32
+	McastStartupQueryCnt    *uint32
+	McastLastMemberIntvl    *uint64
+	McastMembershipIntvl    *uint64
+	McastQuerierIntvl       *uint64
+	McastQueryIntvl         *uint64
+	McastQueryResponseIntvl *uint64
+	McastStartupQueryIntvl  *uint64
+
+	// netfilter properties
+	NfCallIptables  *bool
+	NfCallIp6tables *bool
+	NfCallArptables *bool
+
+	// read only state
+	BridgeId               *BridgeId
+	RootId                 *BridgeId
+	RootPort               uint16
+	RootPathCost           uint32
+	TopologyChange         bool
+	TopologyChangeDetected bool
+	HelloTimer             uint64
+	TcnTimer               uint64
+	TopologyChangeTimer    uint64
+	GcTimer                uint64
+}
+
+// VlanTunnel maps a bridge port vlan to a tunnel id, e.g. a vxlan vni.
+type VlanTunnel struct {
+	Vid      uint16
+	TunnelId uint32
+}
+
+// BridgeId is a spanning tree bridge identifier.
+type BridgeId struct {
+	Priority uint16
+	Address  net.HardwareAddr
 }
 
 func (b *Bridge) Marshal(ctx *Context) ([]byte, error) {
@@ -87,6 +162,89 @@ func (b *Bridge) Marshal(ctx *Context) ([]byte, error) {
 			if b.VlanAware {
 				ae2.Uint8(IFLA_BR_VLAN_FILTERING, 1)
 			}
+
+			if b.StpState != nil {
+				ae2.Uint32(IFLA_BR_STP_STATE, *b.StpState)
+			}
+			if b.ForwardDelay != nil {
+				ae2.Uint32(IFLA_BR_FORWARD_DELAY, *b.ForwardDelay)
+			}
+			if b.HelloTime != nil {
+				ae2.Uint32(IFLA_BR_HELLO_TIME, *b.HelloTime)
+			}
+			if b.MaxAge != nil {
+				ae2.Uint32(IFLA_BR_MAX_AGE, *b.MaxAge)
+			}
+			if b.AgeingTime != nil {
+				ae2.Uint32(IFLA_BR_AGEING_TIME, *b.AgeingTime)
+			}
+			if b.Priority != nil {
+				ae2.Uint16(IFLA_BR_PRIORITY, *b.Priority)
+			}
+
+			if b.VlanProtocol != nil {
+				ae2.Uint16(IFLA_BR_VLAN_PROTOCOL, htons(*b.VlanProtocol))
+			}
+			if b.DefaultPvid != nil {
+				ae2.Uint16(IFLA_BR_VLAN_DEFAULT_PVID, *b.DefaultPvid)
+			}
+			if b.GroupFwdMask != nil {
+				ae2.Uint16(IFLA_BR_GROUP_FWD_MASK, *b.GroupFwdMask)
+			}
+
+			if b.McastRouter != nil {
+				ae2.Uint8(IFLA_BR_MCAST_ROUTER, *b.McastRouter)
+			}
+			if b.McastSnooping != nil {
+				ae2.Uint8(IFLA_BR_MCAST_SNOOPING, boolByte(*b.McastSnooping))
+			}
+			if b.McastQueryUseIfaddr != nil {
+				ae2.Uint8(IFLA_BR_MCAST_QUERY_USE_IFADDR, boolByte(*b.McastQueryUseIfaddr))
+			}
+			if b.McastQuerier != nil {
+				ae2.Uint8(IFLA_BR_MCAST_QUERIER, boolByte(*b.McastQuerier))
+			}
+			if b.McastHashElasticity != nil {
+				ae2.Uint32(IFLA_BR_MCAST_HASH_ELASTICITY, *b.McastHashElasticity)
+			}
+			if b.McastHashMax != nil {
+				ae2.Uint32(IFLA_BR_MCAST_HASH_MAX, *b.McastHashMax)
+			}
+			if b.McastLastMemberCnt != nil {
+				ae2.Uint32(IFLA_BR_MCAST_LAST_MEMBER_CNT, *b.McastLastMemberCnt)
+			}
+			if b.McastStartupQueryCnt != nil {
+				ae2.Uint32(IFLA_BR_MCAST_STARTUP_QUERY_CNT, *b.McastStartupQueryCnt)
+			}
+			if b.McastLastMemberIntvl != nil {
+				ae2.Uint64(IFLA_BR_MCAST_LAST_MEMBER_INTVL, *b.McastLastMemberIntvl)
+			}
+			if b.McastMembershipIntvl != nil {
+				ae2.Uint64(IFLA_BR_MCAST_MEMBERSHIP_INTVL, *b.McastMembershipIntvl)
+			}
+			if b.McastQuerierIntvl != nil {
+				ae2.Uint64(IFLA_BR_MCAST_QUERIER_INTVL, *b.McastQuerierIntvl)
+			}
+			if b.McastQueryIntvl != nil {
+				ae2.Uint64(IFLA_BR_MCAST_QUERY_INTVL, *b.McastQueryIntvl)
+			}
+			if b.McastQueryResponseIntvl != nil {
+				ae2.Uint64(IFLA_BR_MCAST_QUERY_RESPONSE_INTVL, *b.McastQueryResponseIntvl)
+			}
+			if b.McastStartupQueryIntvl != nil {
+				ae2.Uint64(IFLA_BR_MCAST_STARTUP_QUERY_INTVL, *b.McastStartupQueryIntvl)
+			}
+
+			if b.NfCallIptables != nil {
+				ae2.Uint8(IFLA_BR_NF_CALL_IPTABLES, boolByte(*b.NfCallIptables))
+			}
+			if b.NfCallIp6tables != nil {
+				ae2.Uint8(IFLA_BR_NF_CALL_IP6TABLES, boolByte(*b.NfCallIp6tables))
+			}
+			if b.NfCallArptables != nil {
+				ae2.Uint8(IFLA_BR_NF_CALL_ARPTABLES, boolByte(*b.NfCallArptables))
+			}
+
 			return ae2.Encode()
 
 		})
@@ -120,6 +278,103 @@ func (b *Bridge) Unmarshal(ctx *Context, buf []byte) error {
 				b.VlanAware = true
 			}
 
+		case IFLA_BR_STP_STATE:
+			x := ad.Uint32()
+			b.StpState = &x
+		case IFLA_BR_FORWARD_DELAY:
+			x := ad.Uint32()
+			b.ForwardDelay = &x
+		case IFLA_BR_HELLO_TIME:
+			x := ad.Uint32()
+			b.HelloTime = &x
+		case IFLA_BR_MAX_AGE:
+			x := ad.Uint32()
+			b.MaxAge = &x
+		case IFLA_BR_AGEING_TIME:
+			x := ad.Uint32()
+			b.AgeingTime = &x
+		case IFLA_BR_PRIORITY:
+			x := ad.Uint16()
+			b.Priority = &x
+
+		case IFLA_BR_VLAN_PROTOCOL:
+			x := ntohs(ad.Uint16())
+			b.VlanProtocol = &x
+		case IFLA_BR_VLAN_DEFAULT_PVID:
+			x := ad.Uint16()
+			b.DefaultPvid = &x
+		case IFLA_BR_GROUP_FWD_MASK:
+			x := ad.Uint16()
+			b.GroupFwdMask = &x
+
+		case IFLA_BR_MCAST_ROUTER:
+			x := ad.Uint8()
+			b.McastRouter = &x
+		case IFLA_BR_MCAST_SNOOPING:
+			b.McastSnooping = decodeBool(ad)
+		case IFLA_BR_MCAST_QUERY_USE_IFADDR:
+			b.McastQueryUseIfaddr = decodeBool(ad)
+		case IFLA_BR_MCAST_QUERIER:
+			b.McastQuerier = decodeBool(ad)
+		case IFLA_BR_MCAST_HASH_ELASTICITY:
+			x := ad.Uint32()
+			b.McastHashElasticity = &x
+		case IFLA_BR_MCAST_HASH_MAX:
+			x := ad.Uint32()
+			b.McastHashMax = &x
+		case IFLA_BR_MCAST_LAST_MEMBER_CNT:
+			x := ad.Uint32()
+			b.McastLastMemberCnt = &x
+		case IFLA_BR_MCAST_STARTUP_QUERY_CNT:
+			x := ad.Uint32()
+			b.McastStartupQueryCnt = &x
+		case IFLA_BR_MCAST_LAST_MEMBER_INTVL:
+			x := ad.Uint64()
+			b.McastLastMemberIntvl = &x
+		case IFLA_BR_MCAST_MEMBERSHIP_INTVL:
+			x := ad.Uint64()
+			b.McastMembershipIntvl = &x
+		case IFLA_BR_MCAST_QUERIER_INTVL:
+			x := ad.Uint64()
+			b.McastQuerierIntvl = &x
+		case IFLA_BR_MCAST_QUERY_INTVL:
+			x := ad.Uint64()
+			b.McastQueryIntvl = &x
+		case IFLA_BR_MCAST_QUERY_RESPONSE_INTVL:
+			x := ad.Uint64()
+			b.McastQueryResponseIntvl = &x
+		case IFLA_BR_MCAST_STARTUP_QUERY_INTVL:
+			x := ad.Uint64()
+			b.McastStartupQueryIntvl = &x
+
+		case IFLA_BR_NF_CALL_IPTABLES:
+			b.NfCallIptables = decodeBool(ad)
+		case IFLA_BR_NF_CALL_IP6TABLES:
+			b.NfCallIp6tables = decodeBool(ad)
+		case IFLA_BR_NF_CALL_ARPTABLES:
+			b.NfCallArptables = decodeBool(ad)
+
+		case IFLA_BR_BRIDGE_ID:
+			b.BridgeId = parseBridgeId(ad.Bytes())
+		case IFLA_BR_ROOT_ID:
+			b.RootId = parseBridgeId(ad.Bytes())
+		case IFLA_BR_ROOT_PORT:
+			b.RootPort = ad.Uint16()
+		case IFLA_BR_ROOT_PATH_COST:
+			b.RootPathCost = ad.Uint32()
+		case IFLA_BR_TOPOLOGY_CHANGE:
+			b.TopologyChange = ad.Uint8() != 0
+		case IFLA_BR_TOPOLOGY_CHANGE_DETECTED:
+			b.TopologyChangeDetected = ad.Uint8() != 0
+		case IFLA_BR_HELLO_TIMER:
+			b.HelloTimer = ad.Uint64()
+		case IFLA_BR_TCN_TIMER:
+			b.TcnTimer = ad.Uint64()
+		case IFLA_BR_TOPOLOGY_CHANGE_TIMER:
+			b.TopologyChangeTimer = ad.Uint64()
+		case IFLA_BR_GC_TIMER:
+			b.GcTimer = ad.Uint64()
+
 		}
 	}
 
@@ -132,3 +387,80 @@ func (b *Bridge) Resolve(ctx *Context) error {
 	return nil
 
 }
+
+// String formats a bridge id the way iproute2 does, e.g. 8000.0a1b2c3d4e5f
+func (id *BridgeId) String() string {
+
+	if id == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%04x.%s",
+		id.Priority, strings.Replace(id.Address.String(), ":", "", -1))
+
+}
+
+// parseBridgeId reads a struct ifla_bridge_id
+func parseBridgeId(buf []byte) *BridgeId {
+
+	if len(buf) < 8 {
+		return nil
+	}
+
+	return &BridgeId{
+		Priority: binary.BigEndian.Uint16(buf[0:2]),
+		Address:  net.HardwareAddr(buf[2:8]),
+	}
+
+}
+
+// vlanInfoBytes builds a struct bridge_vlan_info
+func vlanInfoBytes(flags, vid uint16) []byte {
+
+	return append(nlenc.Uint16Bytes(flags), nlenc.Uint16Bytes(vid)...)
+
+}
+
+// vlanTunnelInfoBytes builds the nested attributes of a vlan tunnel info entry
+func vlanTunnelInfoBytes(id uint32, vid, flags uint16) func() ([]byte, error) {
+
+	return func() ([]byte, error) {
+
+		ae := netlink.NewAttributeEncoder()
+		ae.Uint32(IFLA_BRIDGE_VLAN_TUNNEL_ID, id)
+		ae.Uint16(IFLA_BRIDGE_VLAN_TUNNEL_VID, vid)
+		if flags != 0 {
+			ae.Uint16(IFLA_BRIDGE_VLAN_TUNNEL_FLAGS, flags)
+		}
+		return ae.Encode()
+
+	}
+
+}
+
+// decodeVlanTunnelInfo reads the nested attributes of a vlan tunnel info entry
+func decodeVlanTunnelInfo(buf []byte) (uint32, uint16, uint16, error) {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	var id uint32
+	var vid, flags uint16
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_BRIDGE_VLAN_TUNNEL_ID:
+			id = ad.Uint32()
+		case IFLA_BRIDGE_VLAN_TUNNEL_VID:
+			vid = ad.Uint16()
+		case IFLA_BRIDGE_VLAN_TUNNEL_FLAGS:
+			flags = ad.Uint16()
+
+		}
+	}
+
+	return id, vid, flags, ad.Err()
+
+}
@@ -0,0 +1,296 @@
+package rtnl
+
+import (
+	"fmt"
+
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// bridge port attribute types
+const (
+	IFLA_BRPORT_UNSPEC uint16 = iota
+	IFLA_BRPORT_STATE
+	IFLA_BRPORT_PRIORITY
+	IFLA_BRPORT_COST
+	IFLA_BRPORT_MODE
+	IFLA_BRPORT_GUARD
+	IFLA_BRPORT_PROTECT
+	IFLA_BRPORT_FAST_LEAVE
+	IFLA_BRPORT_LEARNING
+	IFLA_BRPORT_UNICAST_FLOOD
+	IFLA_BRPORT_PROXYARP
+	IFLA_BRPORT_LEARNING_SYNC
+	IFLA_BRPORT_PROXYARP_WIFI
+	IFLA_BRPORT_ROOT_ID
+	IFLA_BRPORT_BRIDGE_ID
+	IFLA_BRPORT_DESIGNATED_PORT
+	IFLA_BRPORT_DESIGNATED_COST
+	IFLA_BRPORT_ID
+	IFLA_BRPORT_NO
+	IFLA_BRPORT_TOPOLOGY_CHANGE_ACK
+	IFLA_BRPORT_CONFIG_PENDING
+	IFLA_BRPORT_MESSAGE_AGE_TIMER
+	IFLA_BRPORT_FORWARD_DELAY_TIMER
+	IFLA_BRPORT_HOLD_TIMER
+	IFLA_BRPORT_FLUSH
+	IFLA_BRPORT_MULTICAST_ROUTER
+	IFLA_BRPORT_PAD
+	IFLA_BRPORT_MCAST_FLOOD
+	IFLA_BRPORT_MCAST_TO_UCAST
+	IFLA_BRPORT_VLAN_TUNNEL
+	IFLA_BRPORT_BCAST_FLOOD
+	IFLA_BRPORT_GROUP_FWD_MASK
+	IFLA_BRPORT_NEIGH_SUPPRESS
+	IFLA_BRPORT_ISOLATED
+	IFLA_BRPORT_BACKUP_PORT
+)
+
+// BridgePortState aliases spanning tree port states in a type safe way
+type BridgePortState uint8
+
+const (
+	BR_STATE_DISABLED BridgePortState = iota
+	BR_STATE_LISTENING
+	BR_STATE_LEARNING
+	BR_STATE_FORWARDING
+	BR_STATE_BLOCKING
+)
+
+// BridgePort encapsulates the per-port properties of a link enslaved to a
+// bridge. Only fields that are set are sent to the kernel, so a BridgePort
+// may describe a partial change to a port.
+type BridgePort struct {
+	State         *BridgePortState
+	Priority      *uint16
+	Cost          *uint32
+	Learning      *bool
+	UnicastFlood  *bool
+	McastFlood    *bool
+	BcastFlood    *bool
+	Hairpin       *bool
+	Isolated      *bool
+	NeighSuppress *bool
+	ProxyArp      *bool
+	McastRouter   *uint8
+	VlanTunnel    *bool
+	BackupPort    *uint32 // interface index, 0 removes the backup port
+}
+
+// Marshal turns a bridge port into a binary rtnetlink set of attributes.
+func (b *BridgePort) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+
+	// the kernel only parses the full set of port attributes when protinfo is
+	// flagged as nested, otherwise it expects a bare state byte
+	ae.Do(unix.IFLA_PROTINFO|unix.NLA_F_NESTED, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+
+		if b.State != nil {
+			ae1.Uint8(IFLA_BRPORT_STATE, uint8(*b.State))
+		}
+		if b.Priority != nil {
+			ae1.Uint16(IFLA_BRPORT_PRIORITY, *b.Priority)
+		}
+		if b.Cost != nil {
+			ae1.Uint32(IFLA_BRPORT_COST, *b.Cost)
+		}
+		if b.Learning != nil {
+			ae1.Uint8(IFLA_BRPORT_LEARNING, boolByte(*b.Learning))
+		}
+		if b.UnicastFlood != nil {
+			ae1.Uint8(IFLA_BRPORT_UNICAST_FLOOD, boolByte(*b.UnicastFlood))
+		}
+		if b.McastFlood != nil {
+			ae1.Uint8(IFLA_BRPORT_MCAST_FLOOD, boolByte(*b.McastFlood))
+		}
+		if b.BcastFlood != nil {
+			ae1.Uint8(IFLA_BRPORT_BCAST_FLOOD, boolByte(*b.BcastFlood))
+		}
+		if b.Hairpin != nil {
+			ae1.Uint8(IFLA_BRPORT_MODE, boolByte(*b.Hairpin))
+		}
+		if b.Isolated != nil {
+			ae1.Uint8(IFLA_BRPORT_ISOLATED, boolByte(*b.Isolated))
+		}
+		if b.NeighSuppress != nil {
+			ae1.Uint8(IFLA_BRPORT_NEIGH_SUPPRESS, boolByte(*b.NeighSuppress))
+		}
+		if b.ProxyArp != nil {
+			ae1.Uint8(IFLA_BRPORT_PROXYARP, boolByte(*b.ProxyArp))
+		}
+		if b.McastRouter != nil {
+			ae1.Uint8(IFLA_BRPORT_MULTICAST_ROUTER, *b.McastRouter)
+		}
+		if b.VlanTunnel != nil {
+			ae1.Uint8(IFLA_BRPORT_VLAN_TUNNEL, boolByte(*b.VlanTunnel))
+		}
+		if b.BackupPort != nil {
+			ae1.Uint32(IFLA_BRPORT_BACKUP_PORT, *b.BackupPort)
+		}
+
+		return ae1.Encode()
+
+	})
+	attrbuf, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode bridge port attributes")
+		return nil, err
+	}
+
+	return attrbuf, nil
+
+}
+
+// Unmarshal reads a bridge port from a binary set of attributes.
+func (b *BridgePort) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create bridge port attribute decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_BRPORT_STATE:
+			state := BridgePortState(ad.Uint8())
+			b.State = &state
+
+		case IFLA_BRPORT_PRIORITY:
+			prio := ad.Uint16()
+			b.Priority = &prio
+
+		case IFLA_BRPORT_COST:
+			cost := ad.Uint32()
+			b.Cost = &cost
+
+		case IFLA_BRPORT_LEARNING:
+			b.Learning = decodeBool(ad)
+
+		case IFLA_BRPORT_UNICAST_FLOOD:
+			b.UnicastFlood = decodeBool(ad)
+
+		case IFLA_BRPORT_MCAST_FLOOD:
+			b.McastFlood = decodeBool(ad)
+
+		case IFLA_BRPORT_BCAST_FLOOD:
+			b.BcastFlood = decodeBool(ad)
+
+		case IFLA_BRPORT_MODE:
+			b.Hairpin = decodeBool(ad)
+
+		case IFLA_BRPORT_ISOLATED:
+			b.Isolated = decodeBool(ad)
+
+		case IFLA_BRPORT_NEIGH_SUPPRESS:
+			b.NeighSuppress = decodeBool(ad)
+
+		case IFLA_BRPORT_PROXYARP:
+			b.ProxyArp = decodeBool(ad)
+
+		case IFLA_BRPORT_MULTICAST_ROUTER:
+			router := ad.Uint8()
+			b.McastRouter = &router
+
+		case IFLA_BRPORT_VLAN_TUNNEL:
+			b.VlanTunnel = decodeBool(ad)
+
+		case IFLA_BRPORT_BACKUP_PORT:
+			port := ad.Uint32()
+			b.BackupPort = &port
+
+		}
+	}
+
+	return nil
+
+}
+
+// Resolve handle attributes
+func (b *BridgePort) Resolve(ctx *Context) error {
+
+	return nil
+
+}
+
+// SetBridgePort applies the set properties of the provided bridge port to this
+// link, which must be enslaved to a bridge.
+func (l *Link) SetBridgePort(ctx *Context, port *BridgePort) error {
+
+	if port == nil {
+		return nil
+	}
+
+	err := l.Read(ctx)
+	if err != nil {
+		return err
+	}
+
+	msg := l.Msg
+	msg.Family = unix.AF_BRIDGE
+	data := IfInfomsgBytes(msg)
+
+	attrs, err := port.Marshal(ctx)
+	if err != nil {
+		return err
+	}
+	data = append(data, attrs...)
+
+	flags := netlink.Request |
+		netlink.Acknowledge |
+		netlink.Excl
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.RTM_SETLINK),
+			Flags: flags,
+		},
+		Data: data,
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
+
+}
+
+func (s BridgePortState) String() string {
+
+	switch s {
+	case BR_STATE_DISABLED:
+		return "disabled"
+	case BR_STATE_LISTENING:
+		return "listening"
+	case BR_STATE_LEARNING:
+		return "learning"
+	case BR_STATE_FORWARDING:
+		return "forwarding"
+	case BR_STATE_BLOCKING:
+		return "blocking"
+	default:
+		return "unknown"
+	}
+
+}
+
+func ParseBridgePortState(state string) (BridgePortState, error) {
+
+	switch state {
+	case "disabled":
+		return BR_STATE_DISABLED, nil
+	case "listening":
+		return BR_STATE_LISTENING, nil
+	case "learning":
+		return BR_STATE_LEARNING, nil
+	case "forwarding":
+		return BR_STATE_FORWARDING, nil
+	case "blocking":
+		return BR_STATE_BLOCKING, nil
+	}
+
+	return 0, fmt.Errorf("undefined bridge port state")
+
+}
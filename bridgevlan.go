@@ -0,0 +1,645 @@
+package rtnl
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// bridge vlan message types
+const (
+	RTM_NEWVLAN = 0x70 + iota
+	RTM_DELVLAN
+	RTM_GETVLAN
+)
+
+const (
+	brVlanMsgLen = 8
+)
+
+// bridge vlan database attribute types
+const (
+	BRIDGE_VLANDB_UNSPEC uint16 = iota
+	BRIDGE_VLANDB_ENTRY
+	BRIDGE_VLANDB_DUMP_FLAGS
+	BRIDGE_VLANDB_GLOBAL_OPTIONS
+)
+
+// bridge vlan database dump flags
+const (
+	BRIDGE_VLANDB_DUMPF_STATS  = 1 << 0
+	BRIDGE_VLANDB_DUMPF_GLOBAL = 1 << 1
+)
+
+// bridge vlan database entry attribute types
+const (
+	BRIDGE_VLANDB_ENTRY_UNSPEC uint16 = iota
+	BRIDGE_VLANDB_ENTRY_INFO
+	BRIDGE_VLANDB_ENTRY_RANGE
+	BRIDGE_VLANDB_ENTRY_STATE
+	BRIDGE_VLANDB_ENTRY_TUNNEL_INFO
+	BRIDGE_VLANDB_ENTRY_STATS
+	BRIDGE_VLANDB_ENTRY_MCAST_ROUTER
+	BRIDGE_VLANDB_ENTRY_MCAST_N_GROUPS
+	BRIDGE_VLANDB_ENTRY_MCAST_MAX_GROUPS
+	BRIDGE_VLANDB_ENTRY_NEIGH_SUPPRESS
+)
+
+// bridge vlan database tunnel info attribute types
+const (
+	BRIDGE_VLANDB_TINFO_UNSPEC uint16 = iota
+	BRIDGE_VLANDB_TINFO_ID
+	BRIDGE_VLANDB_TINFO_CMD
+)
+
+// bridge vlan database stats attribute types
+const (
+	BRIDGE_VLANDB_STATS_UNSPEC uint16 = iota
+	BRIDGE_VLANDB_STATS_RX_BYTES
+	BRIDGE_VLANDB_STATS_RX_PACKETS
+	BRIDGE_VLANDB_STATS_TX_BYTES
+	BRIDGE_VLANDB_STATS_TX_PACKETS
+	BRIDGE_VLANDB_STATS_PAD
+)
+
+// bridge vlan database global option attribute types
+const (
+	BRIDGE_VLANDB_GOPTS_UNSPEC uint16 = iota
+	BRIDGE_VLANDB_GOPTS_ID
+	BRIDGE_VLANDB_GOPTS_RANGE
+	BRIDGE_VLANDB_GOPTS_MCAST_SNOOPING
+	BRIDGE_VLANDB_GOPTS_MCAST_IGMP_VERSION
+	BRIDGE_VLANDB_GOPTS_MCAST_MLD_VERSION
+	BRIDGE_VLANDB_GOPTS_MCAST_LAST_MEMBER_CNT
+	BRIDGE_VLANDB_GOPTS_MCAST_STARTUP_QUERY_CNT
+	BRIDGE_VLANDB_GOPTS_MCAST_LAST_MEMBER_INTVL
+	BRIDGE_VLANDB_GOPTS_PAD
+	BRIDGE_VLANDB_GOPTS_MCAST_MEMBERSHIP_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERIER_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERY_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERY_RESPONSE_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_STARTUP_QUERY_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERIER
+	BRIDGE_VLANDB_GOPTS_MCAST_ROUTER_PORTS
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERIER_STATE
+	BRIDGE_VLANDB_GOPTS_MSTI
+)
+
+// Data Structures ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// BridgeVlan is a vlan, or range of vlans, on a bridge or bridge port as kept
+// in the kernel's bridge vlan database. Options that are pointers are only
+// sent to the kernel when set.
+type BridgeVlan struct {
+	Ifindex uint32
+	Vid     uint16
+	Range   uint16 // last vid of the range, 0 for a single vlan
+	Flags   uint16 // BRIDGE_VLAN_INFO_* flags
+
+	// per vlan options
+	State         *BridgePortState
+	McastRouter   *uint8
+	NeighSuppress *bool
+
+	// read only state
+	McastGroups uint32
+	Stats       *BridgeVlanStats
+}
+
+// BridgeVlanStats holds per vlan counters.
+type BridgeVlanStats struct {
+	RxBytes   uint64
+	RxPackets uint64
+	TxBytes   uint64
+	TxPackets uint64
+}
+
+// BridgeVlanGlobal holds bridge wide options of a vlan, or range of vlans.
+// Options that are pointers are only sent to the kernel when set. Intervals
+// are in hundredths of a second.
+type BridgeVlanGlobal struct {
+	Ifindex uint32 // the bridge
+	Vid     uint16
+	Range   uint16 // last vid of the range, 0 for a single vlan
+
+	McastSnooping           *bool
+	McastQuerier            *bool
+	McastIgmpVersion        *uint8
+	McastMldVersion         *uint8
+	McastLastMemberCnt      *uint32
+	McastStartupQueryCnt    *uint32
+	McastLastMemberIntvl    *uint64
+	McastMembershipIntvl    *uint64
+	McastQuerierIntvl       *uint64
+	McastQueryIntvl         *uint64
+	McastQueryResponseIntvl *uint64
+	McastStartupQueryIntvl  *uint64
+	Msti                    *uint16
+}
+
+// Methods ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// Marshal turns a bridge vlan into a binary rtnetlink vlan database entry.
+func (v *BridgeVlan) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(BRIDGE_VLANDB_ENTRY|unix.NLA_F_NESTED, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(BRIDGE_VLANDB_ENTRY_INFO, vlanInfoBytes(v.Flags, v.Vid))
+
+		if v.Range > v.Vid {
+			ae1.Uint16(BRIDGE_VLANDB_ENTRY_RANGE, v.Range)
+		}
+		if v.State != nil {
+			ae1.Uint8(BRIDGE_VLANDB_ENTRY_STATE, uint8(*v.State))
+		}
+		if v.McastRouter != nil {
+			ae1.Uint8(BRIDGE_VLANDB_ENTRY_MCAST_ROUTER, *v.McastRouter)
+		}
+		if v.NeighSuppress != nil {
+			ae1.Uint8(BRIDGE_VLANDB_ENTRY_NEIGH_SUPPRESS, boolByte(*v.NeighSuppress))
+		}
+
+		return ae1.Encode()
+
+	})
+	attrbuf, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode bridge vlan attributes")
+		return nil, err
+	}
+
+	return attrbuf, nil
+
+}
+
+// Unmarshal reads a bridge vlan from the attributes of a vlan database entry.
+func (v *BridgeVlan) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create bridge vlan attribute decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+
+		case BRIDGE_VLANDB_ENTRY_INFO:
+			bs := ad.Bytes()
+			if len(bs) >= 4 {
+				v.Flags = nlenc.Uint16(bs[:2])
+				v.Vid = nlenc.Uint16(bs[2:4])
+			}
+
+		case BRIDGE_VLANDB_ENTRY_RANGE:
+			v.Range = ad.Uint16()
+
+		case BRIDGE_VLANDB_ENTRY_STATE:
+			state := BridgePortState(ad.Uint8())
+			v.State = &state
+
+		case BRIDGE_VLANDB_ENTRY_MCAST_ROUTER:
+			router := ad.Uint8()
+			v.McastRouter = &router
+
+		case BRIDGE_VLANDB_ENTRY_NEIGH_SUPPRESS:
+			v.NeighSuppress = decodeBool(ad)
+
+		case BRIDGE_VLANDB_ENTRY_MCAST_N_GROUPS:
+			v.McastGroups = ad.Uint32()
+
+		case BRIDGE_VLANDB_ENTRY_STATS:
+			v.Stats = &BridgeVlanStats{}
+			ad.Do(v.Stats.unmarshal)
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+func (s *BridgeVlanStats) unmarshal(buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+
+		case BRIDGE_VLANDB_STATS_RX_BYTES:
+			s.RxBytes = ad.Uint64()
+		case BRIDGE_VLANDB_STATS_RX_PACKETS:
+			s.RxPackets = ad.Uint64()
+		case BRIDGE_VLANDB_STATS_TX_BYTES:
+			s.TxBytes = ad.Uint64()
+		case BRIDGE_VLANDB_STATS_TX_PACKETS:
+			s.TxPackets = ad.Uint64()
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+// Marshal turns bridge vlan global options into a binary rtnetlink attribute.
+func (g *BridgeVlanGlobal) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(BRIDGE_VLANDB_GLOBAL_OPTIONS|unix.NLA_F_NESTED, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Uint16(BRIDGE_VLANDB_GOPTS_ID, g.Vid)
+
+		if g.Range > g.Vid {
+			ae1.Uint16(BRIDGE_VLANDB_GOPTS_RANGE, g.Range)
+		}
+		if g.McastSnooping != nil {
+			ae1.Uint8(BRIDGE_VLANDB_GOPTS_MCAST_SNOOPING, boolByte(*g.McastSnooping))
+		}
+		if g.McastQuerier != nil {
+			ae1.Uint8(BRIDGE_VLANDB_GOPTS_MCAST_QUERIER, boolByte(*g.McastQuerier))
+		}
+		if g.McastIgmpVersion != nil {
+			ae1.Uint8(BRIDGE_VLANDB_GOPTS_MCAST_IGMP_VERSION, *g.McastIgmpVersion)
+		}
+		if g.McastMldVersion != nil {
+			ae1.Uint8(BRIDGE_VLANDB_GOPTS_MCAST_MLD_VERSION, *g.McastMldVersion)
+		}
+		if g.McastLastMemberCnt != nil {
+			ae1.Uint32(BRIDGE_VLANDB_GOPTS_MCAST_LAST_MEMBER_CNT, *g.McastLastMemberCnt)
+		}
+		if g.McastStartupQueryCnt != nil {
+			ae1.Uint32(BRIDGE_VLANDB_GOPTS_MCAST_STARTUP_QUERY_CNT, *g.McastStartupQueryCnt)
+		}
+		if g.McastLastMemberIntvl != nil {
+			ae1.Uint64(BRIDGE_VLANDB_GOPTS_MCAST_LAST_MEMBER_INTVL, *g.McastLastMemberIntvl)
+		}
+		if g.McastMembershipIntvl != nil {
+			ae1.Uint64(BRIDGE_VLANDB_GOPTS_MCAST_MEMBERSHIP_INTVL, *g.McastMembershipIntvl)
+		}
+		if g.McastQuerierIntvl != nil {
+			ae1.Uint64(BRIDGE_VLANDB_GOPTS_MCAST_QUERIER_INTVL, *g.McastQuerierIntvl)
+		}
+		if g.McastQueryIntvl != nil {
+			ae1.Uint64(BRIDGE_VLANDB_GOPTS_MCAST_QUERY_INTVL, *g.McastQueryIntvl)
+		}
+		if g.McastQueryResponseIntvl != nil {
+			ae1.Uint64(BRIDGE_VLANDB_GOPTS_MCAST_QUERY_RESPONSE_INTVL, *g.McastQueryResponseIntvl)
+		}
+		if g.McastStartupQueryIntvl != nil {
+			ae1.Uint64(BRIDGE_VLANDB_GOPTS_MCAST_STARTUP_QUERY_INTVL, *g.McastStartupQueryIntvl)
+		}
+		if g.Msti != nil {
+			ae1.Uint16(BRIDGE_VLANDB_GOPTS_MSTI, *g.Msti)
+		}
+
+		return ae1.Encode()
+
+	})
+	attrbuf, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode bridge vlan global attributes")
+		return nil, err
+	}
+
+	return attrbuf, nil
+
+}
+
+// Unmarshal reads bridge vlan global options from a binary set of attributes.
+func (g *BridgeVlanGlobal) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create bridge vlan global decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+
+		case BRIDGE_VLANDB_GOPTS_ID:
+			g.Vid = ad.Uint16()
+		case BRIDGE_VLANDB_GOPTS_RANGE:
+			g.Range = ad.Uint16()
+		case BRIDGE_VLANDB_GOPTS_MCAST_SNOOPING:
+			g.McastSnooping = decodeBool(ad)
+		case BRIDGE_VLANDB_GOPTS_MCAST_QUERIER:
+			g.McastQuerier = decodeBool(ad)
+		case BRIDGE_VLANDB_GOPTS_MCAST_IGMP_VERSION:
+			x := ad.Uint8()
+			g.McastIgmpVersion = &x
+		case BRIDGE_VLANDB_GOPTS_MCAST_MLD_VERSION:
+			x := ad.Uint8()
+			g.McastMldVersion = &x
+		case BRIDGE_VLANDB_GOPTS_MCAST_LAST_MEMBER_CNT:
+			x := ad.Uint32()
+			g.McastLastMemberCnt = &x
+		case BRIDGE_VLANDB_GOPTS_MCAST_STARTUP_QUERY_CNT:
+			x := ad.Uint32()
+			g.McastStartupQueryCnt = &x
+		case BRIDGE_VLANDB_GOPTS_MCAST_LAST_MEMBER_INTVL:
+			x := ad.Uint64()
+			g.McastLastMemberIntvl = &x
+		case BRIDGE_VLANDB_GOPTS_MCAST_MEMBERSHIP_INTVL:
+			x := ad.Uint64()
+			g.McastMembershipIntvl = &x
+		case BRIDGE_VLANDB_GOPTS_MCAST_QUERIER_INTVL:
+			x := ad.Uint64()
+			g.McastQuerierIntvl = &x
+		case BRIDGE_VLANDB_GOPTS_MCAST_QUERY_INTVL:
+			x := ad.Uint64()
+			g.McastQueryIntvl = &x
+		case BRIDGE_VLANDB_GOPTS_MCAST_QUERY_RESPONSE_INTVL:
+			x := ad.Uint64()
+			g.McastQueryResponseIntvl = &x
+		case BRIDGE_VLANDB_GOPTS_MCAST_STARTUP_QUERY_INTVL:
+			x := ad.Uint64()
+			g.McastStartupQueryIntvl = &x
+		case BRIDGE_VLANDB_GOPTS_MSTI:
+			x := ad.Uint16()
+			g.Msti = &x
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+// ReadBridgeVlans reads the vlan database entries, including per vlan stats,
+// of the bridge or bridge port identified by spec. A nil spec or zero index
+// reads the entries of all bridges and ports.
+func ReadBridgeVlans(ctx *Context, spec *BridgeVlan) ([]*BridgeVlan, error) {
+
+	if spec == nil {
+		spec = &BridgeVlan{}
+	}
+
+	var result []*BridgeVlan
+
+	err := dumpBridgeVlans(ctx, spec.Ifindex, BRIDGE_VLANDB_DUMPF_STATS,
+		func(ifindex uint32, typ uint16, buf []byte) error {
+
+			if typ != BRIDGE_VLANDB_ENTRY {
+				return nil
+			}
+
+			v := &BridgeVlan{Ifindex: ifindex}
+			err := v.Unmarshal(ctx, buf)
+			if err != nil {
+				return err
+			}
+
+			if spec.Vid != 0 && !v.Contains(spec.Vid) {
+				return nil
+			}
+
+			result = append(result, v)
+			return nil
+
+		})
+
+	return result, err
+
+}
+
+// ReadBridgeVlanGlobals reads the bridge wide vlan options of the bridge with
+// the provided index.
+func ReadBridgeVlanGlobals(ctx *Context, ifindex uint32) ([]*BridgeVlanGlobal, error) {
+
+	var result []*BridgeVlanGlobal
+
+	err := dumpBridgeVlans(ctx, ifindex, BRIDGE_VLANDB_DUMPF_GLOBAL,
+		func(ifindex uint32, typ uint16, buf []byte) error {
+
+			if typ != BRIDGE_VLANDB_GLOBAL_OPTIONS {
+				return nil
+			}
+
+			g := &BridgeVlanGlobal{Ifindex: ifindex}
+			err := g.Unmarshal(ctx, buf)
+			if err != nil {
+				return err
+			}
+
+			result = append(result, g)
+			return nil
+
+		})
+
+	return result, err
+
+}
+
+// Contains returns true if the vid is within this bridge vlan's range.
+func (v *BridgeVlan) Contains(vid uint16) bool {
+
+	if v.Range == 0 {
+		return v.Vid == vid
+	}
+
+	return vid >= v.Vid && vid <= v.Range
+
+}
+
+// Add creates the vlan on its bridge or bridge port and applies any options
+// that are set.
+func (v *BridgeVlan) Add(ctx *Context) error {
+
+	// a pvid is a single vlan, the kernel rejects it on a range
+	if v.Range > v.Vid && v.Flags&BRIDGE_VLAN_INFO_PVID != 0 {
+		return fmt.Errorf("pvid can not be set on vlan range %d-%d", v.Vid, v.Range)
+	}
+
+	lnk := &Link{Msg: unix.IfInfomsg{Index: int32(v.Ifindex)}}
+	err := lnk.Read(ctx)
+	if err != nil {
+		return err
+	}
+
+	// vlans on the bridge itself must be addressed to the bridge, not its
+	// master
+	self := lnk.Info.Bridge != nil
+
+	err = lnk.bridgeAfSpec(ctx, false, func(ae *netlink.AttributeEncoder) {
+
+		if v.Range > v.Vid {
+			ae.Bytes(IFLA_BRIDGE_VLAN_INFO,
+				vlanInfoBytes(v.Flags|BRIDGE_VLAN_INFO_RANGE_BEGIN, v.Vid))
+			ae.Bytes(IFLA_BRIDGE_VLAN_INFO,
+				vlanInfoBytes(v.Flags|BRIDGE_VLAN_INFO_RANGE_END, v.Range))
+		} else {
+			ae.Bytes(IFLA_BRIDGE_VLAN_INFO, vlanInfoBytes(v.Flags, v.Vid))
+		}
+
+		if self {
+			ae.Uint16(IFLA_BRIDGE_FLAGS, BRIDGE_FLAGS_SELF)
+		}
+
+	})
+	if err != nil {
+		return err
+	}
+
+	if v.State == nil && v.McastRouter == nil && v.NeighSuppress == nil {
+		return nil
+	}
+
+	return v.Set(ctx)
+
+}
+
+// Set applies the options that are set on this bridge vlan to the kernel. The
+// vlan must already exist.
+func (v *BridgeVlan) Set(ctx *Context) error {
+
+	return v.Modify(ctx, RTM_NEWVLAN)
+
+}
+
+// Del removes the vlan from its bridge or bridge port.
+func (v *BridgeVlan) Del(ctx *Context) error {
+
+	return v.Modify(ctx, RTM_DELVLAN)
+
+}
+
+// Modify changes the bridge vlan according to the supplied operation.
+// Supported operations are RTM_NEWVLAN and RTM_DELVLAN.
+func (v *BridgeVlan) Modify(ctx *Context, op uint16) error {
+
+	data, err := v.Marshal(ctx)
+	if err != nil {
+		return err
+	}
+
+	return bridgeVlanUpdate(ctx, v.Ifindex, op, data)
+
+}
+
+// Set applies the options that are set on these global vlan options to the
+// bridge.
+func (g *BridgeVlanGlobal) Set(ctx *Context) error {
+
+	data, err := g.Marshal(ctx)
+	if err != nil {
+		return err
+	}
+
+	return bridgeVlanUpdate(ctx, g.Ifindex, RTM_NEWVLAN, data)
+
+}
+
+// helpers ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// brVlanMsgBytes builds a struct br_vlan_msg
+func brVlanMsgBytes(ifindex uint32) []byte {
+
+	msg := make([]byte, brVlanMsgLen)
+	msg[0] = unix.AF_BRIDGE
+	binary.LittleEndian.PutUint32(msg[4:8], ifindex)
+	return msg
+
+}
+
+func bridgeVlanUpdate(ctx *Context, ifindex uint32, op uint16, attrs []byte) error {
+
+	if ifindex == 0 {
+		return fmt.Errorf("bridge vlan requires an interface index")
+	}
+
+	flags := netlink.Request |
+		netlink.Acknowledge
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(op),
+			Flags: flags,
+		},
+		Data: append(brVlanMsgBytes(ifindex), attrs...),
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
+
+}
+
+// dumpBridgeVlans dumps the vlan database and hands each top level attribute
+// of each response to f along with the index of the link it belongs to.
+func dumpBridgeVlans(
+	ctx *Context,
+	ifindex uint32,
+	dumpFlags uint32,
+	f func(uint32, uint16, []byte) error,
+) error {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(BRIDGE_VLANDB_DUMP_FLAGS, dumpFlags)
+	attrs, err := ae.Encode()
+	if err != nil {
+		return err
+	}
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  RTM_GETVLAN,
+			Flags: netlink.Request | netlink.Dump,
+		},
+		Data: append(brVlanMsgBytes(ifindex), attrs...),
+	}
+
+	return withNsNetlink(ctx.Fd(), func(conn *netlink.Conn) error {
+
+		resp, err := conn.Execute(m)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resp {
+			err := unmarshalBrVlanMsg(r.Data, f)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	})
+
+}
+
+// unmarshalBrVlanMsg hands each top level attribute of a bridge vlan message
+// to f along with the index of the link it belongs to.
+func unmarshalBrVlanMsg(buf []byte, f func(uint32, uint16, []byte) error) error {
+
+	if len(buf) < brVlanMsgLen {
+		return nil
+	}
+	idx := binary.LittleEndian.Uint32(buf[4:8])
+
+	ad, err := netlink.NewAttributeDecoder(buf[brVlanMsgLen:])
+	if err != nil {
+		log.WithError(err).Error("error creating bridge vlan decoder")
+		return err
+	}
+
+	for ad.Next() {
+		err := f(idx, ad.Type()&nlaTypeMask, ad.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	return ad.Err()
+
+}
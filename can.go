@@ -0,0 +1,268 @@
+package rtnl
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// can attribute types
+const (
+	IFLA_CAN_UNSPEC uint16 = iota
+	IFLA_CAN_BITTIMING
+	IFLA_CAN_BITTIMING_CONST
+	IFLA_CAN_CLOCK
+	IFLA_CAN_STATE
+	IFLA_CAN_CTRLMODE
+	IFLA_CAN_RESTART_MS
+	IFLA_CAN_RESTART
+	IFLA_CAN_BERR_COUNTER
+	IFLA_CAN_DATA_BITTIMING
+	IFLA_CAN_DATA_BITTIMING_CONST
+	IFLA_CAN_TERMINATION
+	IFLA_CAN_TERMINATION_CONST
+	IFLA_CAN_BITRATE_CONST
+	IFLA_CAN_DATA_BITRATE_CONST
+	IFLA_CAN_BITRATE_MAX
+)
+
+// CanState aliases CAN controller states in a type safe way
+type CanState uint32
+
+const (
+	CAN_STATE_ERROR_ACTIVE CanState = iota
+	CAN_STATE_ERROR_WARNING
+	CAN_STATE_ERROR_PASSIVE
+	CAN_STATE_BUS_OFF
+	CAN_STATE_STOPPED
+	CAN_STATE_SLEEPING
+)
+
+// can controller modes
+const (
+	CAN_CTRLMODE_LOOPBACK uint32 = 1 << iota
+	CAN_CTRLMODE_LISTENONLY
+	CAN_CTRLMODE_3_SAMPLES
+	CAN_CTRLMODE_ONE_SHOT
+	CAN_CTRLMODE_BERR_REPORTING
+	CAN_CTRLMODE_FD
+	CAN_CTRLMODE_PRESUME_ACK
+	CAN_CTRLMODE_FD_NON_ISO
+)
+
+const (
+	canBittimingLen = 32
+	canCtrlmodeLen  = 8
+	canBerrLen      = 4
+)
+
+// CanBittiming holds the bit timing of a CAN controller, struct can_bittiming
+type CanBittiming struct {
+	Bitrate     uint32
+	SamplePoint uint32 // in tenths of a percent
+	Tq          uint32 // time quantum in nanoseconds
+	PropSeg     uint32
+	PhaseSeg1   uint32
+	PhaseSeg2   uint32
+	Sjw         uint32
+	Brp         uint32
+}
+
+// CanCtrlmode holds the controller mode flags, only the flags in Mask are
+// meaningful when setting.
+type CanCtrlmode struct {
+	Mask  uint32
+	Flags uint32
+}
+
+// CanBerrCounter holds the transmit and receive error counters of a CAN
+// controller.
+type CanBerrCounter struct {
+	TxErr uint16
+	RxErr uint16
+}
+
+// Can encapsulates information about CAN controllers. These are physical
+// devices, so the properties are read back rather than used at creation.
+type Can struct {
+	Bittiming     *CanBittiming
+	DataBittiming *CanBittiming
+	Clock         uint32
+	State         CanState
+	Ctrlmode      *CanCtrlmode
+	RestartMs     uint32
+	BerrCounter   *CanBerrCounter
+}
+
+// Marshal turns a can into a binary rtnetlink set of attributes. Only the
+// settable properties are encoded.
+func (c *Can) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte("can"))
+		ae1.Do(IFLA_INFO_DATA, func() ([]byte, error) {
+
+			ae2 := netlink.NewAttributeEncoder()
+
+			if c.Bittiming != nil {
+				ae2.Bytes(IFLA_CAN_BITTIMING, c.Bittiming.marshal())
+			}
+			if c.DataBittiming != nil {
+				ae2.Bytes(IFLA_CAN_DATA_BITTIMING, c.DataBittiming.marshal())
+			}
+
+			if c.Ctrlmode != nil {
+				buf := make([]byte, canCtrlmodeLen)
+				binary.LittleEndian.PutUint32(buf[0:4], c.Ctrlmode.Mask)
+				binary.LittleEndian.PutUint32(buf[4:8], c.Ctrlmode.Flags)
+				ae2.Bytes(IFLA_CAN_CTRLMODE, buf)
+			}
+
+			if c.RestartMs != 0 {
+				ae2.Uint32(IFLA_CAN_RESTART_MS, c.RestartMs)
+			}
+
+			return ae2.Encode()
+
+		})
+
+		return ae1.Encode()
+
+	})
+	attrbuf, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode can attributes")
+		return nil, err
+	}
+
+	return attrbuf, nil
+
+}
+
+// Unmarshal reads a can from a binary set of attributes.
+func (c *Can) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create can attribute decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_CAN_BITTIMING:
+			c.Bittiming, err = unmarshalCanBittiming(ad.Bytes())
+			if err != nil {
+				return err
+			}
+
+		case IFLA_CAN_DATA_BITTIMING:
+			c.DataBittiming, err = unmarshalCanBittiming(ad.Bytes())
+			if err != nil {
+				return err
+			}
+
+		case IFLA_CAN_CLOCK:
+			c.Clock = ad.Uint32()
+
+		case IFLA_CAN_STATE:
+			c.State = CanState(ad.Uint32())
+
+		case IFLA_CAN_CTRLMODE:
+			buf := ad.Bytes()
+			if len(buf) < canCtrlmodeLen {
+				return fmt.Errorf("short can ctrlmode")
+			}
+			c.Ctrlmode = &CanCtrlmode{
+				Mask:  binary.LittleEndian.Uint32(buf[0:4]),
+				Flags: binary.LittleEndian.Uint32(buf[4:8]),
+			}
+
+		case IFLA_CAN_RESTART_MS:
+			c.RestartMs = ad.Uint32()
+
+		case IFLA_CAN_BERR_COUNTER:
+			buf := ad.Bytes()
+			if len(buf) < canBerrLen {
+				return fmt.Errorf("short can error counter")
+			}
+			c.BerrCounter = &CanBerrCounter{
+				TxErr: binary.LittleEndian.Uint16(buf[0:2]),
+				RxErr: binary.LittleEndian.Uint16(buf[2:4]),
+			}
+
+		}
+	}
+
+	return nil
+
+}
+
+// Resolve handle attributes
+func (c *Can) Resolve(ctx *Context) error {
+
+	return nil
+
+}
+
+func (b *CanBittiming) marshal() []byte {
+
+	buf := make([]byte, canBittimingLen)
+	for i, x := range []uint32{
+		b.Bitrate, b.SamplePoint, b.Tq, b.PropSeg,
+		b.PhaseSeg1, b.PhaseSeg2, b.Sjw, b.Brp,
+	} {
+		binary.LittleEndian.PutUint32(buf[i*4:], x)
+	}
+	return buf
+
+}
+
+func unmarshalCanBittiming(buf []byte) (*CanBittiming, error) {
+
+	if len(buf) < canBittimingLen {
+		return nil, fmt.Errorf("short can bittiming")
+	}
+
+	u := func(i int) uint32 { return binary.LittleEndian.Uint32(buf[i*4:]) }
+
+	return &CanBittiming{
+		Bitrate:     u(0),
+		SamplePoint: u(1),
+		Tq:          u(2),
+		PropSeg:     u(3),
+		PhaseSeg1:   u(4),
+		PhaseSeg2:   u(5),
+		Sjw:         u(6),
+		Brp:         u(7),
+	}, nil
+
+}
+
+func (s CanState) String() string {
+
+	switch s {
+	case CAN_STATE_ERROR_ACTIVE:
+		return "error-active"
+	case CAN_STATE_ERROR_WARNING:
+		return "error-warning"
+	case CAN_STATE_ERROR_PASSIVE:
+		return "error-passive"
+	case CAN_STATE_BUS_OFF:
+		return "bus-off"
+	case CAN_STATE_STOPPED:
+		return "stopped"
+	case CAN_STATE_SLEEPING:
+		return "sleeping"
+	default:
+		return "unknown"
+	}
+
+}
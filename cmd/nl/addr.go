@@ -0,0 +1,156 @@
+package main
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
+
+	"gitlab.com/mergetb/tech/rtnl"
+)
+
+func addrCommands(root *cobra.Command) {
+
+	addr := &cobra.Command{
+		Use:   "addr",
+		Short: "address command family",
+	}
+	root.AddCommand(addr)
+
+	var replace, nodad, noprefixroute, managetempaddr, home bool
+	var valid, preferred uint32
+	add := &cobra.Command{
+		Use:   "add <device> <address>/<prefix>",
+		Short: "add address to device",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			doAddr(args[0], args[1], func(ctx *rtnl.Context, a *rtnl.Address) error {
+				a.Info.Nodad = nodad
+				a.Info.NoPrefixRoute = noprefixroute
+				a.Info.ManageTempAddr = managetempaddr
+				a.Info.HomeAddress = home
+				// a zero preferred lifetime deprecates the address, so the
+				// lifetimes are only set when given
+				validSet := cmd.Flags().Changed("valid-lft")
+				preferredSet := cmd.Flags().Changed("preferred-lft")
+				if validSet || preferredSet {
+					a.Info.CacheInfo = &rtnl.AddrCacheInfo{
+						Valid:     rtnl.INFINITY_LIFE_TIME,
+						Preferred: rtnl.INFINITY_LIFE_TIME,
+					}
+					if validSet {
+						a.Info.CacheInfo.Valid = valid
+					}
+					if preferredSet {
+						a.Info.CacheInfo.Preferred = preferred
+					}
+				}
+				if replace {
+					return a.Replace(ctx)
+				}
+				return a.Add(ctx)
+			})
+		},
+	}
+	add.Flags().BoolVarP(&replace, "replace", "r", false, "update the address if it exists")
+	add.Flags().BoolVar(&nodad, "nodad", false, "skip duplicate address detection")
+	add.Flags().BoolVar(&noprefixroute, "noprefixroute", false, "do not add a prefix route")
+	add.Flags().BoolVar(&managetempaddr, "managetempaddr", false, "manage temporary addresses from this prefix")
+	add.Flags().BoolVar(&home, "home", false, "designate as a mobile ipv6 home address")
+	add.Flags().Uint32Var(&valid, "valid-lft", 0, "valid lifetime in seconds (default forever)")
+	add.Flags().Uint32Var(&preferred, "preferred-lft", 0, "preferred lifetime in seconds (default forever)")
+	addr.AddCommand(add)
+
+	del := &cobra.Command{
+		Use:   "del <device> <address>/<prefix>",
+		Short: "delete address from device",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			doAddr(args[0], args[1], func(ctx *rtnl.Context, a *rtnl.Address) error {
+				return a.Del(ctx)
+			})
+		},
+	}
+	addr.AddCommand(del)
+
+	var family string
+	flush := &cobra.Command{
+		Use:   "flush <device>",
+		Short: "delete all addresses from device",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			doAddrFlush(args[0], family)
+		},
+	}
+	flush.Flags().StringVarP(&family, "family", "f", "", "only flush this family (inet|inet6)")
+	addr.AddCommand(flush)
+
+}
+
+func doAddr(device, address string, f func(*rtnl.Context, *rtnl.Address) error) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk, err := rtnl.GetLink(ctx, device)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	a, err := rtnl.ParseAddr(address)
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.Msg.Index = uint32(lnk.Msg.Index)
+
+	err = f(ctx, a)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func doAddrFlush(device, family string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk, err := rtnl.GetLink(ctx, device)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	addrs, err := lnk.Addrs(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, a := range addrs {
+		switch family {
+		case "inet":
+			if a.Msg.Family != unix.AF_INET {
+				continue
+			}
+		case "inet6":
+			if a.Msg.Family != unix.AF_INET6 {
+				continue
+			}
+		case "":
+		default:
+			log.Fatalf("unknown family %s", family)
+		}
+		// deleting a primary ipv4 address may take its secondaries with it, so
+		// addresses that are already gone are not an error
+		err = a.Absent(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+}
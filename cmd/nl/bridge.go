@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/mergetb/tech/rtnl"
+)
+
+func bridgeCommands(root *cobra.Command) {
+
+	bridge := &cobra.Command{
+		Use:   "bridge",
+		Short: "bridge command family",
+	}
+	root.AddCommand(bridge)
+
+	vlan := &cobra.Command{
+		Use:   "vlan",
+		Short: "bridge vlan database commands",
+	}
+	bridge.AddCommand(vlan)
+
+	var dev string
+	vlanShow := &cobra.Command{
+		Use:   "show",
+		Short: "show bridge vlans with their state and counters",
+		Args:  cobra.NoArgs,
+		Run:   func(cmd *cobra.Command, args []string) { bridgeVlanShow(dev) },
+	}
+	vlanShow.Flags().StringVarP(&dev, "dev", "d", "", "bridge or bridge port")
+	vlan.AddCommand(vlanShow)
+
+	vlanState := &cobra.Command{
+		Use:   "state <dev> <vid>[-<vid>] <state>",
+		Short: "set the stp state of bridge vlans",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			bridgeVlanState(args[0], args[1], args[2])
+		},
+	}
+	vlan.AddCommand(vlanState)
+
+	mdb := &cobra.Command{
+		Use:   "mdb",
+		Short: "bridge multicast database commands",
+	}
+	bridge.AddCommand(mdb)
+
+	var mdbDev string
+	mdbShow := &cobra.Command{
+		Use:   "show",
+		Short: "show multicast group memberships and router ports",
+		Args:  cobra.NoArgs,
+		Run:   func(cmd *cobra.Command, args []string) { bridgeMdbShow(mdbDev) },
+	}
+	mdbShow.Flags().StringVarP(&mdbDev, "dev", "d", "", "bridge")
+	mdb.AddCommand(mdbShow)
+
+	var vid uint16
+	var permanent bool
+	var source string
+	var include bool
+	var sources []string
+	mdbAdd := &cobra.Command{
+		Use:   "add <bridge> <port> <group>",
+		Short: "add a multicast group membership to a bridge port",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			e := mdbEntry(args[0], args[1], args[2], vid, source, sources)
+			e.Permanent = permanent
+			if include {
+				e.GroupMode = rtnl.MCAST_INCLUDE
+			}
+			bridgeMdbModify(e, true)
+		},
+	}
+	mdbAdd.Flags().Uint16VarP(&vid, "vid", "v", 0, "vlan id")
+	mdbAdd.Flags().BoolVarP(&permanent, "permanent", "p", false, "permanent entry")
+	mdbAdd.Flags().StringVarP(&source, "source", "s", "", "source of an (S, G) entry")
+	mdbAdd.Flags().BoolVarP(&include, "include", "i", false, "include filter mode")
+	mdbAdd.Flags().StringSliceVar(&sources, "sources", nil, "igmpv3/mldv2 source list")
+	mdb.AddCommand(mdbAdd)
+
+	mdbDel := &cobra.Command{
+		Use:   "del <bridge> <port> <group>",
+		Short: "remove a multicast group membership from a bridge port",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			bridgeMdbModify(mdbEntry(args[0], args[1], args[2], vid, source, nil), false)
+		},
+	}
+	mdbDel.Flags().Uint16VarP(&vid, "vid", "v", 0, "vlan id")
+	mdbDel.Flags().StringVarP(&source, "source", "s", "", "source of an (S, G) entry")
+	mdb.AddCommand(mdbDel)
+
+}
+
+func bridgeVlanShow(dev string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	spec := &rtnl.BridgeVlan{}
+	if dev != "" {
+		lnk, err := rtnl.GetLink(ctx, dev)
+		if err != nil {
+			log.Fatal(err)
+		}
+		spec.Ifindex = uint32(lnk.Msg.Index)
+	}
+
+	vlans, err := rtnl.ReadBridgeVlans(ctx, spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		"dev",
+		"vlan",
+		"state",
+		"rx-packets",
+		"rx-bytes",
+		"tx-packets",
+		"tx-bytes",
+	)
+
+	for _, v := range vlans {
+
+		vids := fmt.Sprintf("%d", v.Vid)
+		if v.Range != 0 {
+			vids = fmt.Sprintf("%d-%d", v.Vid, v.Range)
+		}
+
+		state := ""
+		if v.State != nil {
+			state = v.State.String()
+		}
+
+		stats := &rtnl.BridgeVlanStats{}
+		if v.Stats != nil {
+			stats = v.Stats
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n",
+			ifLabel(ctx, v.Ifindex),
+			vids,
+			state,
+			stats.RxPackets,
+			stats.RxBytes,
+			stats.TxPackets,
+			stats.TxBytes,
+		)
+
+	}
+
+	tw.Flush()
+
+}
+
+func bridgeVlanState(dev, vids, state string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk, err := rtnl.GetLink(ctx, dev)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	begin, end, err := parseVidRange(vids)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s, err := rtnl.ParseBridgePortState(state)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	v := &rtnl.BridgeVlan{
+		Ifindex: uint32(lnk.Msg.Index),
+		Vid:     begin,
+		State:   &s,
+	}
+	if end != begin {
+		v.Range = end
+	}
+
+	err = v.Set(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func bridgeMdbShow(dev string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	spec := &rtnl.MdbEntry{}
+	if dev != "" {
+		lnk, err := rtnl.GetLink(ctx, dev)
+		if err != nil {
+			log.Fatal(err)
+		}
+		spec.Bridge = uint32(lnk.Msg.Index)
+	}
+
+	entries, err := rtnl.ReadMdb(ctx, spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+		"bridge",
+		"port",
+		"group",
+		"vlan",
+		"state",
+		"sources",
+	)
+
+	for _, e := range entries {
+
+		state := "temp"
+		if e.Permanent {
+			state = "permanent"
+		}
+
+		group := e.Group.String()
+		if e.Source != nil {
+			group = fmt.Sprintf("(%s, %s)", e.Source, e.Group)
+		}
+
+		srcs := ""
+		for i, s := range e.Sources {
+			if i > 0 {
+				srcs += ","
+			}
+			srcs += s.Address.String()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			ifLabel(ctx, e.Bridge),
+			ifLabel(ctx, e.Port),
+			group,
+			e.Vid,
+			state,
+			srcs,
+		)
+
+	}
+
+	tw.Flush()
+
+	routers, err := rtnl.ReadMdbRouters(ctx, spec.Bridge)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(routers) == 0 {
+		return
+	}
+
+	fmt.Fprintf(tw, "\n%s\t%s\t%s\n", "bridge", "router-port", "timer")
+	for _, r := range routers {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n",
+			ifLabel(ctx, r.Bridge),
+			ifLabel(ctx, r.Port),
+			r.Timer,
+		)
+	}
+
+	tw.Flush()
+
+}
+
+func mdbEntry(
+	bridge, port, group string, vid uint16, source string, sources []string,
+) *rtnl.MdbEntry {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	br, err := rtnl.GetLink(ctx, bridge)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p, err := rtnl.GetLink(ctx, port)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e := &rtnl.MdbEntry{
+		Bridge: uint32(br.Msg.Index),
+		Port:   uint32(p.Msg.Index),
+		Group:  net.ParseIP(group),
+		Vid:    vid,
+	}
+	if e.Group == nil {
+		log.Fatalf("bad group address %s", group)
+	}
+
+	if source != "" {
+		e.Source = net.ParseIP(source)
+		if e.Source == nil {
+			log.Fatalf("bad source address %s", source)
+		}
+	}
+
+	for _, s := range sources {
+		addr := net.ParseIP(s)
+		if addr == nil {
+			log.Fatalf("bad source address %s", s)
+		}
+		e.Sources = append(e.Sources, rtnl.MdbSource{Address: addr})
+	}
+
+	return e
+
+}
+
+func bridgeMdbModify(e *rtnl.MdbEntry, add bool) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	if add {
+		err = rtnl.AddMdb(ctx, e)
+	} else {
+		err = rtnl.DelMdb(ctx, e)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
@@ -36,6 +36,15 @@ func linkCommands(root *cobra.Command) {
 	list.Flags().StringVarP(&bridge, "bridge", "b", "", "filter on bridge")
 	link.AddCommand(list)
 
+	// conf
+	conf := &cobra.Command{
+		Use:   "conf <name>",
+		Short: "show link ipv4 and ipv6 configuration",
+		Args:  cobra.ExactArgs(1),
+		Run:   func(cmd *cobra.Command, args []string) { doShowConf(args[0]) },
+	}
+	link.AddCommand(conf)
+
 	// up
 	up := &cobra.Command{
 		Use:   "up <name>",
@@ -72,25 +81,58 @@ func linkCommands(root *cobra.Command) {
 
 	// addBridge
 	var (
-		brinfo *rtnl.Bridge = &rtnl.Bridge{}
+		brinfo  *rtnl.Bridge = &rtnl.Bridge{}
+		stp     bool
+		brprio  uint16
+		ageing  uint32
+		fwdMask uint16
 	)
 	addBridge := &cobra.Command{
 		Use:   "bridge <name>",
 		Short: "add a bridge",
 		Args:  cobra.ExactArgs(1),
-		Run:   func(cmd *cobra.Command, args []string) { doAddBridge(args[0], brinfo) },
+		Run: func(cmd *cobra.Command, args []string) {
+			if cmd.Flags().Changed("stp") {
+				var state uint32
+				if stp {
+					state = 1
+				}
+				brinfo.StpState = &state
+			}
+			if cmd.Flags().Changed("priority") {
+				brinfo.Priority = &brprio
+			}
+			if cmd.Flags().Changed("ageing") {
+				brinfo.AgeingTime = &ageing
+			}
+			if cmd.Flags().Changed("group-fwd-mask") {
+				brinfo.GroupFwdMask = &fwdMask
+			}
+			doAddBridge(args[0], brinfo)
+		},
 	}
 	addBridge.Flags().BoolVarP(
 		&brinfo.VlanAware, "vlan-aware", "v", false, "vlan aware bridge")
+	addBridge.Flags().BoolVarP(&stp, "stp", "s", false, "enable spanning tree")
+	addBridge.Flags().Uint16VarP(&brprio, "priority", "p", 0, "spanning tree priority")
+	addBridge.Flags().Uint32Var(
+		&ageing, "ageing", 0, "fdb ageing time in hundredths of a second")
+	addBridge.Flags().Uint16Var(
+		&fwdMask, "group-fwd-mask", 0, "group forwarding mask")
 	add.AddCommand(addBridge)
 
 	// addVxlan
 	var (
-		vxinfo   *rtnl.Vxlan = &rtnl.Vxlan{}
-		learning bool
-		local    string
-		vxlink   string
-		vxbr     string
+		vxinfo    *rtnl.Vxlan = &rtnl.Vxlan{}
+		learning  bool
+		udpCsum   bool
+		zeroTx    bool
+		zeroRx    bool
+		local     string
+		group     string
+		portRange string
+		vxlink    string
+		vxbr      string
 	)
 	addVxlan := &cobra.Command{
 		Use:   "vxlan <name> <vni>",
@@ -102,6 +144,31 @@ func linkCommands(root *cobra.Command) {
 			}
 			if local != "" {
 				vxinfo.Local = net.ParseIP(local)
+				if vxinfo.Local == nil {
+					log.Fatal("invalid local address")
+				}
+			}
+			if group != "" {
+				vxinfo.Group = net.ParseIP(group)
+				if vxinfo.Group == nil {
+					log.Fatal("invalid group address")
+				}
+			}
+			if portRange != "" {
+				pr, err := parsePortRange(portRange)
+				if err != nil {
+					log.Fatal(err)
+				}
+				vxinfo.PortRange = pr
+			}
+			if cmd.Flags().Changed("udpcsum") {
+				vxinfo.UdpCsum = &udpCsum
+			}
+			if cmd.Flags().Changed("udp6zerocsumtx") {
+				vxinfo.UdpZeroCsum6Tx = &zeroTx
+			}
+			if cmd.Flags().Changed("udp6zerocsumrx") {
+				vxinfo.UdpZeroCsum6Rx = &zeroRx
 			}
 			vni, err := strconv.Atoi(args[1])
 			if err != nil {
@@ -114,27 +181,211 @@ func linkCommands(root *cobra.Command) {
 	addVxlan.Flags().BoolVarP(&learning, "learning", "l", false, "enable mac learning")
 	addVxlan.Flags().Uint16VarP(&vxinfo.DstPort, "dstport", "d", 4789, "destination port")
 	addVxlan.Flags().StringVarP(&local, "local", "t", "", "local tunnel IP")
+	addVxlan.Flags().StringVarP(&group, "group", "g", "", "remote or multicast group IP")
 	addVxlan.Flags().StringVarP(&vxlink, "link", "i", "", "parent link")
 	addVxlan.Flags().StringVarP(&vxbr, "bridge", "b", "", "add to bridge")
+	addVxlan.Flags().Uint8Var(&vxinfo.Ttl, "ttl", 0, "tunnel ttl")
+	addVxlan.Flags().BoolVar(&vxinfo.TtlInherit, "ttl-inherit", false, "inherit ttl from inner packet")
+	addVxlan.Flags().Uint8Var(&vxinfo.Tos, "tos", 0, "tunnel tos")
+	addVxlan.Flags().Uint32Var(&vxinfo.Ageing, "ageing", 0, "fdb entry lifetime in seconds")
+	addVxlan.Flags().Uint32Var(&vxinfo.Limit, "limit", 0, "maximum number of fdb entries")
+	addVxlan.Flags().StringVar(&portRange, "port-range", "", "udp source port range <low>-<high>")
+	addVxlan.Flags().BoolVar(&vxinfo.Proxy, "proxy", false, "enable arp proxy")
+	addVxlan.Flags().BoolVar(&vxinfo.Rsc, "rsc", false, "enable route short circuit")
+	addVxlan.Flags().BoolVar(&vxinfo.L2miss, "l2miss", false, "enable layer 2 miss notifications")
+	addVxlan.Flags().BoolVar(&vxinfo.L3miss, "l3miss", false, "enable layer 3 miss notifications")
+	addVxlan.Flags().BoolVar(&udpCsum, "udpcsum", false, "compute udp checksum")
+	addVxlan.Flags().BoolVar(&zeroTx, "udp6zerocsumtx", false, "skip udp checksum for ipv6 tx")
+	addVxlan.Flags().BoolVar(&zeroRx, "udp6zerocsumrx", false, "allow zero udp checksum for ipv6 rx")
+	addVxlan.Flags().BoolVar(&vxinfo.Gbp, "gbp", false, "enable group policy extension")
+	addVxlan.Flags().BoolVarP(&vxinfo.CollectMetadata, "external", "e", false, "collect metadata")
+	addVxlan.Flags().Uint32Var(&vxinfo.Label, "label", 0, "ipv6 flow label")
 	add.AddCommand(addVxlan)
 
+	// addGeneve
+	var (
+		gninfo *rtnl.Geneve = &rtnl.Geneve{}
+		gnrmt  string
+		gndf   string
+		gnbr   string
+	)
+	addGeneve := &cobra.Command{
+		Use:   "geneve <name> [vni]",
+		Short: "add geneve",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if gnrmt != "" {
+				gninfo.Remote = net.ParseIP(gnrmt)
+				if gninfo.Remote == nil {
+					log.Fatal("invalid remote address")
+				}
+			}
+			df, err := rtnl.ParseGeneveDf(gndf)
+			if err != nil {
+				log.Fatal(err)
+			}
+			gninfo.Df = df
+			// an external geneve device takes its vni from the tunnel metadata
+			if len(args) < 2 {
+				if !gninfo.CollectMetadata {
+					log.Fatal("vni required unless --external is set")
+				}
+			} else {
+				vni, err := strconv.Atoi(args[1])
+				if err != nil {
+					log.Fatal(err)
+				}
+				gninfo.Vni = uint32(vni)
+			}
+			doAddGeneve(args[0], gnbr, gninfo)
+		},
+	}
+	addGeneve.Flags().StringVarP(&gnrmt, "remote", "r", "", "remote tunnel IP")
+	addGeneve.Flags().Uint8Var(&gninfo.Ttl, "ttl", 0, "tunnel ttl")
+	addGeneve.Flags().BoolVar(&gninfo.TtlInherit, "ttl-inherit", false, "inherit ttl from inner packet")
+	addGeneve.Flags().Uint8Var(&gninfo.Tos, "tos", 0, "tunnel tos")
+	addGeneve.Flags().Uint16VarP(&gninfo.DstPort, "dstport", "d", 6081, "destination port")
+	addGeneve.Flags().BoolVar(&gninfo.UdpCsum, "udpcsum", false, "compute udp checksum")
+	addGeneve.Flags().BoolVar(&gninfo.UdpZeroCsum6Tx, "udp6zerocsumtx", false, "skip udp checksum for ipv6 tx")
+	addGeneve.Flags().BoolVar(&gninfo.UdpZeroCsum6Rx, "udp6zerocsumrx", false, "allow zero udp checksum for ipv6 rx")
+	addGeneve.Flags().BoolVarP(&gninfo.CollectMetadata, "external", "e", false, "collect metadata")
+	addGeneve.Flags().Uint32Var(&gninfo.Label, "label", 0, "ipv6 flow label")
+	addGeneve.Flags().StringVar(&gndf, "df", "unset", "don't fragment (unset|set|inherit)")
+	addGeneve.Flags().StringVarP(&gnbr, "bridge", "b", "", "add to bridge")
+	add.AddCommand(addGeneve)
+
 	// addVeth
 	var (
-		vethNS string
-		vebr   string
+		vethNS   string
+		vebr     string
+		peerNS   string
+		peerMac  string
+		peerInfo rtnl.LinkInfo
 	)
 	addVeth := &cobra.Command{
 		Use:   "veth <name> <peer>",
 		Short: "create a veth pair",
 		Args:  cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			doAddVeth(args[0], args[1], vethNS, vebr)
+			doAddVeth(args[0], args[1], vethNS, vebr, peerNS, peerMac, &peerInfo)
 		},
 	}
 	addVeth.Flags().StringVarP(&vethNS, "namespace", "n", "", "network namespace")
 	addVeth.Flags().StringVarP(&vebr, "bridge", "b", "", "add veth to bridge")
+	addVeth.Flags().StringVar(&peerNS, "peer-namespace", "", "peer network namespace")
+	addVeth.Flags().Uint32Var(&peerInfo.NsPid, "peer-pid", 0, "peer network namespace of process")
+	addVeth.Flags().StringVar(&peerMac, "peer-address", "", "peer mac address")
+	addVeth.Flags().Uint32Var(&peerInfo.Mtu, "peer-mtu", 0, "peer mtu")
+	addVeth.Flags().Uint32Var(&peerInfo.TxQLen, "peer-txqlen", 0, "peer transmit queue length")
+	addVeth.Flags().StringVar(&peerInfo.Alias, "peer-alias", "", "peer alias")
 	add.AddCommand(addVeth)
 
+	// addTun/addTap
+	var (
+		ttinfo  rtnl.TunTap
+		ttowner int
+		ttgroup int
+	)
+	tunTapCmd := func(typ string) *cobra.Command {
+		cmd := &cobra.Command{
+			Use:   typ + " <name>",
+			Short: "create a persistent " + typ + " interface",
+			Args:  cobra.ExactArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				doAddTunTap(args[0], typ, ttinfo, ttowner, ttgroup)
+			},
+		}
+		cmd.Flags().IntVarP(&ttowner, "user", "u", -1, "owning user id")
+		cmd.Flags().IntVarP(&ttgroup, "group", "g", -1, "owning group id")
+		cmd.Flags().BoolVar(&ttinfo.PacketInfo, "pi", false, "packet information header")
+		cmd.Flags().BoolVar(&ttinfo.VnetHdr, "vnet-hdr", false, "virtio net header")
+		cmd.Flags().BoolVarP(&ttinfo.MultiQueue, "multi-queue", "m", false, "multiple queues")
+		return cmd
+	}
+	add.AddCommand(tunTapCmd("tun"))
+	add.AddCommand(tunTapCmd("tap"))
+
+	// addDummy/addIfb/addNlmon/addVcan
+	for _, typ := range []string{"dummy", "ifb", "nlmon", "vcan"} {
+		typ := typ
+		add.AddCommand(&cobra.Command{
+			Use:   typ + " <name>",
+			Short: "create " + typ + " interface",
+			Args:  cobra.ExactArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				doAddSimple(args[0], typ)
+			},
+		})
+	}
+
+	// addXfrm
+	var (
+		xfinfo *rtnl.Xfrm = &rtnl.Xfrm{}
+		xfdev  string
+	)
+	addXfrm := &cobra.Command{
+		Use:   "xfrm <name> <if-id>",
+		Short: "create xfrm interface",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				log.Fatal(err)
+			}
+			xfinfo.IfId = uint32(id)
+			lnk := &rtnl.Link{Info: &rtnl.LinkInfo{Name: args[0], Xfrm: xfinfo}}
+			doAddTunnel(lnk, xfdev, &xfinfo.Link)
+		},
+	}
+	addXfrm.Flags().StringVarP(&xfdev, "dev", "d", "", "underlying device")
+	addXfrm.Flags().BoolVarP(&xfinfo.CollectMetadata, "external", "e", false, "collect metadata")
+	add.AddCommand(addXfrm)
+
+	// addVti
+	var (
+		vtinfo   *rtnl.Vti = &rtnl.Vti{}
+		vtlocal  string
+		vtremote string
+		vtkey    uint32
+		vtdev    string
+	)
+	addVti := &cobra.Command{
+		Use:   "vti <name>",
+		Short: "create vti or vti6 interface, the kind follows the endpoint family",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			vtinfo.Local = parseTunnelIP(vtlocal)
+			vtinfo.Remote = parseTunnelIP(vtremote)
+			if cmd.Flags().Changed("key") {
+				vtinfo.Ikey = vtkey
+				vtinfo.Okey = vtkey
+			}
+			lnk := &rtnl.Link{Info: &rtnl.LinkInfo{Name: args[0], Vti: vtinfo}}
+			doAddTunnel(lnk, vtdev, &vtinfo.Link)
+		},
+	}
+	addVti.Flags().StringVarP(&vtlocal, "local", "l", "", "local tunnel IP")
+	addVti.Flags().StringVarP(&vtremote, "remote", "r", "", "remote tunnel IP")
+	addVti.Flags().Uint32VarP(&vtkey, "key", "k", 0, "input and output key")
+	addVti.Flags().Uint32Var(&vtinfo.Ikey, "ikey", 0, "input key")
+	addVti.Flags().Uint32Var(&vtinfo.Okey, "okey", 0, "output key")
+	addVti.Flags().Uint32Var(&vtinfo.Fwmark, "fwmark", 0, "firewall mark of tunneled packets")
+	addVti.Flags().StringVarP(&vtdev, "dev", "d", "", "underlying device")
+	add.AddCommand(addVti)
+
+	// addVxcan
+	var vxcanNS string
+	addVxcan := &cobra.Command{
+		Use:   "vxcan <name> <peer>",
+		Short: "create a vxcan pair",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			doAddVxcan(args[0], args[1], vxcanNS)
+		},
+	}
+	addVxcan.Flags().StringVar(&vxcanNS, "peer-namespace", "", "peer network namespace")
+	add.AddCommand(addVxcan)
+
 	// addWireguard
 	addWireguard := &cobra.Command{
 		Use:   "wg <name>",
@@ -159,15 +410,19 @@ func linkCommands(root *cobra.Command) {
 		self     bool
 	)
 	vlanCmd := &cobra.Command{
-		Use:   "vlan <name> <vid>",
+		Use:   "vlan <name> <vid>[-<vid>]",
 		Short: "set link vlan",
 		Args:  cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			vid, err := strconv.Atoi(args[1])
+			begin, end, err := parseVidRange(args[1])
 			if err != nil {
 				log.Fatal(err)
 			}
-			doVlan(args[0], vid, false, untagged, pvid, self)
+			if begin == end {
+				doVlan(args[0], int(begin), false, untagged, pvid, self)
+			} else {
+				doVlanRange(args[0], begin, end, false, untagged, self)
+			}
 		},
 	}
 	vlanCmd.Flags().BoolVarP(&untagged, "untagged", "u", false, "untagged vlan")
@@ -175,6 +430,24 @@ func linkCommands(root *cobra.Command) {
 	vlanCmd.Flags().BoolVarP(&self, "self", "s", false, "bridge vlan")
 	set.AddCommand(vlanCmd)
 
+	vlanTunnelCmd := &cobra.Command{
+		Use:   "vlan-tunnel <name> <vid>[-<vid>] <tunnel-id>",
+		Short: "set link vlan to tunnel id mapping",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			begin, end, err := parseVidRange(args[1])
+			if err != nil {
+				log.Fatal(err)
+			}
+			id, err := strconv.ParseUint(args[2], 10, 32)
+			if err != nil {
+				log.Fatal(err)
+			}
+			doVlanTunnel(args[0], begin, end, uint32(id), false)
+		},
+	}
+	set.AddCommand(vlanTunnelCmd)
+
 	mtuCmd := &cobra.Command{
 		Use:   "mtu <name> <mtu>",
 		Short: "set link mtu",
@@ -189,6 +462,227 @@ func linkCommands(root *cobra.Command) {
 	}
 	set.AddCommand(mtuCmd)
 
+	var (
+		bpState       string
+		bpPriority    uint16
+		bpCost        uint32
+		bpLearning    bool
+		bpFlood       bool
+		bpMcastFlood  bool
+		bpBcastFlood  bool
+		bpHairpin     bool
+		bpIsolated    bool
+		bpNeighSupp   bool
+		bpProxyArp    bool
+		bpMcastRouter uint8
+		bpBackup      string
+	)
+	bridgePortCmd := &cobra.Command{
+		Use:   "bridgeport <name>",
+		Short: "set bridge port properties",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			port := &rtnl.BridgePort{}
+			flags := cmd.Flags()
+
+			if flags.Changed("state") {
+				state, err := rtnl.ParseBridgePortState(bpState)
+				if err != nil {
+					log.Fatal(err)
+				}
+				port.State = &state
+			}
+			if flags.Changed("priority") {
+				port.Priority = &bpPriority
+			}
+			if flags.Changed("cost") {
+				port.Cost = &bpCost
+			}
+			if flags.Changed("learning") {
+				port.Learning = &bpLearning
+			}
+			if flags.Changed("flood") {
+				port.UnicastFlood = &bpFlood
+			}
+			if flags.Changed("mcast-flood") {
+				port.McastFlood = &bpMcastFlood
+			}
+			if flags.Changed("bcast-flood") {
+				port.BcastFlood = &bpBcastFlood
+			}
+			if flags.Changed("hairpin") {
+				port.Hairpin = &bpHairpin
+			}
+			if flags.Changed("isolated") {
+				port.Isolated = &bpIsolated
+			}
+			if flags.Changed("neigh-suppress") {
+				port.NeighSuppress = &bpNeighSupp
+			}
+			if flags.Changed("proxyarp") {
+				port.ProxyArp = &bpProxyArp
+			}
+			if flags.Changed("mcast-router") {
+				port.McastRouter = &bpMcastRouter
+			}
+
+			doBridgePort(args[0], bpBackup, flags.Changed("backup-port"), port)
+
+		},
+	}
+	bridgePortCmd.Flags().StringVar(&bpState, "state", "", "stp state (disabled|listening|learning|forwarding|blocking)")
+	bridgePortCmd.Flags().Uint16Var(&bpPriority, "priority", 0, "stp port priority")
+	bridgePortCmd.Flags().Uint32Var(&bpCost, "cost", 0, "stp path cost")
+	bridgePortCmd.Flags().BoolVar(&bpLearning, "learning", true, "mac learning")
+	bridgePortCmd.Flags().BoolVar(&bpFlood, "flood", true, "unicast flooding")
+	bridgePortCmd.Flags().BoolVar(&bpMcastFlood, "mcast-flood", true, "multicast flooding")
+	bridgePortCmd.Flags().BoolVar(&bpBcastFlood, "bcast-flood", true, "broadcast flooding")
+	bridgePortCmd.Flags().BoolVar(&bpHairpin, "hairpin", false, "hairpin mode")
+	bridgePortCmd.Flags().BoolVar(&bpIsolated, "isolated", false, "isolated port")
+	bridgePortCmd.Flags().BoolVar(&bpNeighSupp, "neigh-suppress", false, "neighbor discovery suppression")
+	bridgePortCmd.Flags().BoolVar(&bpProxyArp, "proxyarp", false, "arp proxy")
+	bridgePortCmd.Flags().Uint8Var(&bpMcastRouter, "mcast-router", 1, "multicast router mode")
+	bridgePortCmd.Flags().StringVar(&bpBackup, "backup-port", "", "backup port, empty to remove")
+	set.AddCommand(bridgePortCmd)
+
+	var (
+		vfMac      string
+		vfVlan     uint32
+		vfQos      uint32
+		vfMinRate  uint32
+		vfMaxRate  uint32
+		vfSpoofChk bool
+		vfTrust    bool
+		vfState    string
+	)
+	vfCmd := &cobra.Command{
+		Use:   "vf <name> <vf>",
+		Short: "set sr-iov virtual function properties",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			vf, err := strconv.ParseUint(args[1], 10, 32)
+			if err != nil {
+				log.Fatal(err)
+			}
+			flags := cmd.Flags()
+
+			doLink(args[0], func(ctx *rtnl.Context, l *rtnl.Link) error {
+				n := uint32(vf)
+				if vfMac != "" {
+					mac, err := net.ParseMAC(vfMac)
+					if err != nil {
+						return err
+					}
+					if err := l.SetVfMac(ctx, n, mac); err != nil {
+						return err
+					}
+				}
+				if flags.Changed("vlan") || flags.Changed("qos") {
+					if err := l.SetVfVlan(ctx, n, vfVlan, vfQos); err != nil {
+						return err
+					}
+				}
+				if flags.Changed("min-rate") || flags.Changed("max-rate") {
+					if err := l.SetVfRate(ctx, n, vfMinRate, vfMaxRate); err != nil {
+						return err
+					}
+				}
+				if flags.Changed("spoofchk") {
+					if err := l.SetVfSpoofChk(ctx, n, vfSpoofChk); err != nil {
+						return err
+					}
+				}
+				if flags.Changed("trust") {
+					if err := l.SetVfTrust(ctx, n, vfTrust); err != nil {
+						return err
+					}
+				}
+				if vfState != "" {
+					state, err := rtnl.ParseVfLinkState(vfState)
+					if err != nil {
+						return err
+					}
+					if err := l.SetVfLinkState(ctx, n, state); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+
+		},
+	}
+	vfCmd.Flags().StringVar(&vfMac, "mac", "", "vf mac address")
+	vfCmd.Flags().Uint32Var(&vfVlan, "vlan", 0, "vf vlan, 0 to disable")
+	vfCmd.Flags().Uint32Var(&vfQos, "qos", 0, "vf vlan priority")
+	vfCmd.Flags().Uint32Var(&vfMinRate, "min-rate", 0, "vf minimum transmit rate in Mbps")
+	vfCmd.Flags().Uint32Var(&vfMaxRate, "max-rate", 0, "vf maximum transmit rate in Mbps")
+	vfCmd.Flags().BoolVar(&vfSpoofChk, "spoofchk", true, "vf spoof checking")
+	vfCmd.Flags().BoolVar(&vfTrust, "trust", false, "vf trust")
+	vfCmd.Flags().StringVar(&vfState, "state", "", "vf link state (auto|enable|disable)")
+	set.AddCommand(vfCmd)
+
+	inetCmd := &cobra.Command{
+		Use:   "inet <name> <setting>=<value> ...",
+		Short: "set link ipv4 configuration",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			conf := make(map[rtnl.Ipv4Devconf]uint32)
+			for _, x := range args[1:] {
+				parts := strings.SplitN(x, "=", 2)
+				if len(parts) != 2 {
+					log.Fatalf("expected <setting>=<value>, got %s", x)
+				}
+				key, err := rtnl.ParseIpv4Devconf(parts[0])
+				if err != nil {
+					log.Fatal(err)
+				}
+				value, err := strconv.ParseUint(parts[1], 10, 32)
+				if err != nil {
+					log.Fatal(err)
+				}
+				conf[key] = uint32(value)
+			}
+			doLink(args[0], func(ctx *rtnl.Context, l *rtnl.Link) error {
+				return l.SetInet4Conf(ctx, conf)
+			})
+		},
+	}
+	set.AddCommand(inetCmd)
+
+	addrGenModeCmd := &cobra.Command{
+		Use:   "addr-gen-mode <name> <mode>",
+		Short: "set link ipv6 address generation mode (eui64|none|stable-privacy|random)",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			mode, err := rtnl.ParseInet6AddrGenMode(args[1])
+			if err != nil {
+				log.Fatal(err)
+			}
+			doLink(args[0], func(ctx *rtnl.Context, l *rtnl.Link) error {
+				return l.SetInet6AddrGenMode(ctx, mode)
+			})
+		},
+	}
+	set.AddCommand(addrGenModeCmd)
+
+	tokenCmd := &cobra.Command{
+		Use:   "token <name> <token>",
+		Short: "set link ipv6 interface identifier token",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			token := net.ParseIP(args[1])
+			if token == nil {
+				log.Fatal("invalid token")
+			}
+			doLink(args[0], func(ctx *rtnl.Context, l *rtnl.Link) error {
+				return l.SetInet6Token(ctx, token)
+			})
+		},
+	}
+	set.AddCommand(tokenCmd)
+
 	// unset
 	unset := &cobra.Command{
 		Use:   "unset",
@@ -197,15 +691,19 @@ func linkCommands(root *cobra.Command) {
 	link.AddCommand(unset)
 
 	noVlanCmd := &cobra.Command{
-		Use:   "vlan <name> <vid>",
+		Use:   "vlan <name> <vid>[-<vid>]",
 		Short: "unset link vlan",
 		Args:  cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			vid, err := strconv.Atoi(args[1])
+			begin, end, err := parseVidRange(args[1])
 			if err != nil {
 				log.Fatal(err)
 			}
-			doVlan(args[0], vid, true, untagged, pvid, self)
+			if begin == end {
+				doVlan(args[0], int(begin), true, untagged, pvid, self)
+			} else {
+				doVlanRange(args[0], begin, end, true, untagged, self)
+			}
 		},
 	}
 	noVlanCmd.Flags().BoolVarP(&untagged, "untagged", "u", false, "untagged vlan")
@@ -213,6 +711,40 @@ func linkCommands(root *cobra.Command) {
 	noVlanCmd.Flags().BoolVarP(&self, "self", "s", false, "bridge vlan")
 	unset.AddCommand(noVlanCmd)
 
+	noVlanTunnelCmd := &cobra.Command{
+		Use:   "vlan-tunnel <name> <vid>[-<vid>] <tunnel-id>",
+		Short: "unset link vlan to tunnel id mapping",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			begin, end, err := parseVidRange(args[1])
+			if err != nil {
+				log.Fatal(err)
+			}
+			id, err := strconv.ParseUint(args[2], 10, 32)
+			if err != nil {
+				log.Fatal(err)
+			}
+			doVlanTunnel(args[0], begin, end, uint32(id), true)
+		},
+	}
+	unset.AddCommand(noVlanTunnelCmd)
+
+	var xdpMode string
+	noXdpCmd := &cobra.Command{
+		Use:   "xdp <name>",
+		Short: "detach link xdp program",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			mode, err := rtnl.ParseXdpMode(xdpMode)
+			if err != nil {
+				log.Fatal(err)
+			}
+			doDetachXdp(args[0], mode)
+		},
+	}
+	noXdpCmd.Flags().StringVarP(&xdpMode, "mode", "m", "auto", "attach mode (auto|generic|driver|offload)")
+	unset.AddCommand(noXdpCmd)
+
 }
 
 func doList(typ, bridge string) {
@@ -256,6 +788,22 @@ func doList(typ, bridge string) {
 		link.Msg.Family = unix.AF_BRIDGE
 		link.Read(ctx)
 
+		// peers in other namespaces are only resolved on request as it queries
+		// every namespace
+		var peered *rtnl.Veth
+		if link.Info.Veth != nil {
+			peered = link.Info.Veth
+		}
+		if link.Info.Vxcan != nil {
+			peered = &link.Info.Vxcan.Veth
+		}
+		if peered != nil {
+			err := peered.ResolvePeerNamespace(ctx)
+			if err != nil {
+				log.Printf("%s: failed to resolve peer: %v", link.Info.Name, err)
+			}
+		}
+
 		showLink(ctx, link)
 	}
 
@@ -383,7 +931,39 @@ func doAddVxlan(name, parent, bridge string, info *rtnl.Vxlan) {
 
 }
 
-func doAddVeth(a, b, namespace, bridge string) {
+func doAddGeneve(name, bridge string, info *rtnl.Geneve) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk := &rtnl.Link{
+		Info: &rtnl.LinkInfo{
+			Name:   name,
+			Geneve: info,
+		},
+	}
+
+	if bridge != "" {
+		b, err := rtnl.GetLink(ctx, bridge)
+		if err != nil {
+			log.Fatal(err)
+		}
+		lnk.Info.Master = uint32(b.Msg.Index)
+	}
+
+	err = lnk.Add(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func doAddVeth(
+	a, b, namespace, bridge, peerNS, peerMac string, peer *rtnl.LinkInfo,
+) {
 
 	var ctx *rtnl.Context
 	var err error
@@ -404,10 +984,26 @@ func doAddVeth(a, b, namespace, bridge string) {
 		Info: &rtnl.LinkInfo{
 			Ns:   uint32(ctx.Fd()),
 			Name: a,
-			Veth: &rtnl.Veth{Peer: b},
+			Veth: &rtnl.Veth{Peer: b, PeerInfo: peer},
 		},
 	}
 
+	if peerNS != "" {
+		pctx, err := rtnl.OpenContext(peerNS)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer pctx.Close()
+		peer.Ns = uint32(pctx.Fd())
+	}
+
+	if peerMac != "" {
+		peer.Address, err = net.ParseMAC(peerMac)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if bridge != "" {
 		b, err := rtnl.GetLink(ctx, bridge)
 		if err != nil {
@@ -423,6 +1019,201 @@ func doAddVeth(a, b, namespace, bridge string) {
 
 }
 
+func doAddTunTap(name, typ string, info rtnl.TunTap, owner, group int) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	if owner >= 0 {
+		uid := uint32(owner)
+		info.Owner = &uid
+	}
+	if group >= 0 {
+		gid := uint32(group)
+		info.Group = &gid
+	}
+
+	lnk := &rtnl.Link{
+		Info: &rtnl.LinkInfo{
+			Name: name,
+		},
+	}
+	if typ == "tap" {
+		lnk.Info.Tap = &rtnl.Tap{TunTap: info}
+	} else {
+		lnk.Info.Tun = &rtnl.Tun{TunTap: info}
+	}
+
+	err = lnk.Add(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func doAddSimple(name, typ string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk := &rtnl.Link{
+		Info: &rtnl.LinkInfo{
+			Name: name,
+		},
+	}
+	lnk.ApplyType(typ)
+
+	err = lnk.Add(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+// doAddTunnel adds a tunnel link, bound to the provided underlying device if
+// one is given.
+func doAddTunnel(lnk *rtnl.Link, dev string, link *uint32) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	if dev != "" {
+		d, err := rtnl.GetLink(ctx, dev)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*link = uint32(d.Msg.Index)
+	}
+
+	err = lnk.Add(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func parseTunnelIP(addr string) net.IP {
+
+	if addr == "" {
+		return nil
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		log.Fatalf("invalid tunnel address %s", addr)
+	}
+	return ip
+
+}
+
+func doLink(name string, f func(*rtnl.Context, *rtnl.Link) error) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk, err := rtnl.GetLink(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = f(ctx, lnk)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func doShowConf(name string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk, err := rtnl.GetLink(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i := 1; i <= len(lnk.Info.Inet4Conf); i++ {
+		key := rtnl.Ipv4Devconf(i)
+		fmt.Fprintf(tw, "ipv4.%s\t%d\n", key, lnk.Info.Inet4Conf[key])
+	}
+	for i := 0; i < len(lnk.Info.Inet6Conf); i++ {
+		key := rtnl.Ipv6Devconf(i)
+		fmt.Fprintf(tw, "ipv6.%s\t%d\n", key, lnk.Info.Inet6Conf[key])
+	}
+	if lnk.Info.Inet6 != nil && lnk.Info.Inet6.Token != nil {
+		fmt.Fprintf(tw, "ipv6.token\t%s\n", lnk.Info.Inet6.Token)
+	}
+	tw.Flush()
+
+}
+
+func doDetachXdp(name string, mode rtnl.XdpMode) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk, err := rtnl.GetLink(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = lnk.DetachXdp(ctx, mode)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func doAddVxcan(a, b, peerNS string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	peer := &rtnl.LinkInfo{Name: b}
+	if peerNS != "" {
+		pctx, err := rtnl.OpenContext(peerNS)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer pctx.Close()
+		peer.Ns = uint32(pctx.Fd())
+	}
+
+	lnk := &rtnl.Link{
+		Info: &rtnl.LinkInfo{
+			Name:  a,
+			Vxcan: &rtnl.Vxcan{Veth: rtnl.Veth{Peer: b, PeerInfo: peer}},
+		},
+	}
+
+	err = lnk.Add(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
 func doAddWg(name string) {
 
 	ctx, err := rtnl.OpenDefaultContext()
@@ -463,6 +1254,44 @@ func doVlan(name string, vni int, unset, untagged, pvid, self bool) {
 
 }
 
+func doVlanRange(name string, begin, end uint16, unset, untagged, self bool) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lnk, err := rtnl.GetLink(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = lnk.SetVlanRange(ctx, begin, end, unset, untagged, self)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func doVlanTunnel(name string, begin, end uint16, id uint32, unset bool) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lnk, err := rtnl.GetLink(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = lnk.SetVlanTunnelRange(ctx, begin, end, id, unset)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
 func doMtu(name string, mtu int) {
 
 	ctx, err := rtnl.OpenDefaultContext()
@@ -482,6 +1311,38 @@ func doMtu(name string, mtu int) {
 
 }
 
+func doBridgePort(name, backup string, setBackup bool, port *rtnl.BridgePort) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk, err := rtnl.GetLink(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if setBackup {
+		var ifx uint32
+		if backup != "" {
+			b, err := rtnl.GetLink(ctx, backup)
+			if err != nil {
+				log.Fatal(err)
+			}
+			ifx = uint32(b.Msg.Index)
+		}
+		port.BackupPort = &ifx
+	}
+
+	err = lnk.SetBridgePort(ctx, port)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
 // helpers ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 type FilterFunc func(link *rtnl.Link) bool
 
@@ -497,6 +1358,50 @@ func bridgeFilter(bridgeid uint32) FilterFunc {
 	}
 }
 
+func parseVidRange(s string) (uint16, uint16, error) {
+
+	parts := strings.Split(s, "-")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("vlan range must be <vid>-<vid>")
+	}
+
+	begin, err := strconv.ParseUint(parts[0], 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid vlan: %v", err)
+	}
+	if len(parts) == 1 {
+		return uint16(begin), uint16(begin), nil
+	}
+
+	end, err := strconv.ParseUint(parts[1], 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid vlan: %v", err)
+	}
+
+	return uint16(begin), uint16(end), nil
+
+}
+
+func parsePortRange(s string) (*rtnl.VxlanPortRange, error) {
+
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("port range must be <low>-<high>")
+	}
+
+	low, err := strconv.ParseUint(parts[0], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid low port: %v", err)
+	}
+	high, err := strconv.ParseUint(parts[1], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid high port: %v", err)
+	}
+
+	return &rtnl.VxlanPortRange{Low: uint16(low), High: uint16(high)}, nil
+
+}
+
 func filter(filter FilterFunc, links []*rtnl.Link) []*rtnl.Link {
 
 	result := []*rtnl.Link{}
@@ -587,6 +1492,62 @@ func props(l *rtnl.Link) string {
 		s += fmt.Sprintf("pvid=%d ", l.Info.Pvid)
 	}
 
+	if l.Info.VlanTunnels != nil {
+		var tunnels []string
+		for _, x := range l.Info.VlanTunnels {
+			tunnels = append(tunnels, fmt.Sprintf("%d:%d", x.Vid, x.TunnelId))
+		}
+		s += fmt.Sprintf("tunnels=[%s] ", strings.Join(tunnels, ","))
+	}
+
+	peer := l.Info.Veth
+	if l.Info.Vxcan != nil {
+		peer = &l.Info.Vxcan.Veth
+	}
+	if peer != nil && peer.Peer != "" {
+		if peer.PeerNamespace != "" {
+			s += fmt.Sprintf("peer=%s@%s ", peer.Peer, peer.PeerNamespace)
+		} else {
+			s += fmt.Sprintf("peer=%s ", peer.Peer)
+		}
+	}
+
+	if l.Info.Xdp != nil {
+		x := l.Info.Xdp
+		var progs []string
+		for _, p := range []struct {
+			mode string
+			id   uint32
+		}{
+			{"generic", x.SkbProgId},
+			{"driver", x.DrvProgId},
+			{"offload", x.HwProgId},
+		} {
+			if p.id != 0 {
+				progs = append(progs, fmt.Sprintf("%s:%d", p.mode, p.id))
+			}
+		}
+		s += fmt.Sprintf("xdp=[%s] ", strings.Join(progs, ","))
+	}
+
+	if l.Info.NumVf != 0 {
+		s += fmt.Sprintf("vfs=%d ", l.Info.NumVf)
+	}
+
+	if l.Info.Can != nil {
+		s += fmt.Sprintf("can-state=%s ", l.Info.Can.State)
+		if l.Info.Can.Bittiming != nil {
+			s += fmt.Sprintf("bitrate=%d ", l.Info.Can.Bittiming.Bitrate)
+		}
+		if l.Info.Can.Ctrlmode != nil {
+			s += fmt.Sprintf("ctrlmode=%#x ", l.Info.Can.Ctrlmode.Flags)
+		}
+	}
+
+	if l.Info.BridgePort != nil && l.Info.BridgePort.State != nil {
+		s += fmt.Sprintf("state=%s ", l.Info.BridgePort.State)
+	}
+
 	s += fmt.Sprintf("mtu=%d ", l.Info.Mtu)
 
 	return s
@@ -599,10 +1560,16 @@ func bridgeProps(l *rtnl.Link) string {
 		return ""
 	}
 
+	s := ""
+
 	if l.Info.Bridge.VlanAware {
-		return "vlan-aware "
+		s += "vlan-aware "
 	}
 
-	return ""
+	if st := l.Info.Bridge.StpState; st != nil && *st != 0 {
+		s += fmt.Sprintf("stp root=%s ", l.Info.Bridge.RootId)
+	}
+
+	return s
 
 }
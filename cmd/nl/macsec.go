@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/hex"
+	"log"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/mergetb/tech/rtnl"
+)
+
+func macsecCommands(root *cobra.Command) {
+
+	macsec := &cobra.Command{
+		Use:   "macsec",
+		Short: "macsec command family",
+	}
+	root.AddCommand(macsec)
+
+	var (
+		info       rtnl.Macsec
+		cipher     string
+		validation string
+		encrypt    bool
+		protect    bool
+		sendSci    bool
+		replay     bool
+	)
+	add := &cobra.Command{
+		Use:   "add <device> <name>",
+		Short: "add macsec link on device",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			flags := cmd.Flags()
+			if cipher != "" {
+				suite, err := rtnl.ParseMacsecCipherSuite(cipher)
+				if err != nil {
+					log.Fatal(err)
+				}
+				info.CipherSuite = suite
+			}
+			if validation != "" {
+				v, err := rtnl.ParseMacsecValidation(validation)
+				if err != nil {
+					log.Fatal(err)
+				}
+				info.Validation = &v
+			}
+			if flags.Changed("encrypt") {
+				info.Encrypt = &encrypt
+			}
+			if flags.Changed("protect") {
+				info.Protect = &protect
+			}
+			if flags.Changed("send-sci") {
+				info.IncludeSci = &sendSci
+			}
+			if flags.Changed("window") || flags.Changed("replay") {
+				info.ReplayProtect = &replay
+			}
+
+			macsecAdd(args[0], args[1], &info)
+
+		},
+	}
+	add.Flags().Uint16VarP(&info.Port, "port", "p", 0, "secure channel port")
+	add.Flags().Uint64Var(&info.Sci, "sci", 0, "secure channel identifier")
+	add.Flags().Uint8Var(&info.IcvLen, "icvlen", 0, "integrity check value length")
+	add.Flags().StringVar(&cipher, "cipher", "", "cipher suite (gcm-aes-128|gcm-aes-256|gcm-aes-xpn-128|gcm-aes-xpn-256)")
+	add.Flags().Uint8Var(&info.EncodingSa, "encodingsa", 0, "association number used for transmit")
+	add.Flags().BoolVarP(&encrypt, "encrypt", "e", false, "encrypt frames")
+	add.Flags().BoolVar(&protect, "protect", true, "protect frames")
+	add.Flags().BoolVar(&sendSci, "send-sci", true, "include the sci in frames")
+	add.Flags().BoolVar(&replay, "replay", false, "replay protection")
+	add.Flags().Uint32Var(&info.Window, "window", 0, "replay protection window")
+	add.Flags().StringVar(&validation, "validate", "", "frame validation (disabled|check|strict)")
+	macsec.AddCommand(add)
+
+	// secure associations
+	var (
+		pn     uint64
+		active bool
+	)
+	saCmd := func(use, short string, nargs int, run func([]string, *rtnl.MacsecSa)) *cobra.Command {
+		cmd := &cobra.Command{
+			Use:   use,
+			Short: short,
+			Args:  cobra.ExactArgs(nargs),
+			Run: func(cmd *cobra.Command, args []string) {
+				sa := &rtnl.MacsecSa{Pn: pn, Active: &active}
+				run(args, sa)
+			},
+		}
+		cmd.Flags().Uint64Var(&pn, "pn", 1, "next packet number")
+		cmd.Flags().BoolVarP(&active, "active", "a", true, "association is active")
+		return cmd
+	}
+
+	txsa := &cobra.Command{
+		Use:   "txsa",
+		Short: "transmit secure association commands",
+	}
+	macsec.AddCommand(txsa)
+
+	txsa.AddCommand(saCmd("add <name> <an> <key-id> <key>", "add transmit association", 4,
+		func(args []string, sa *rtnl.MacsecSa) {
+			parseSa(sa, args[1], args[2], args[3])
+			macsecDo(args[0], func(ctx *rtnl.Context, ifx uint32) error {
+				return rtnl.AddMacsecTxSa(ctx, ifx, sa)
+			})
+		}))
+
+	txsa.AddCommand(&cobra.Command{
+		Use:   "del <name> <an>",
+		Short: "delete transmit association",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			an := parseAn(args[1])
+			macsecDo(args[0], func(ctx *rtnl.Context, ifx uint32) error {
+				return rtnl.DelMacsecTxSa(ctx, ifx, an)
+			})
+		},
+	})
+
+	rxsc := &cobra.Command{
+		Use:   "rxsc",
+		Short: "receive secure channel commands",
+	}
+	macsec.AddCommand(rxsc)
+
+	rxsc.AddCommand(&cobra.Command{
+		Use:   "add <name> <sci>",
+		Short: "add receive channel",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			sci := parseSci(args[1])
+			macsecDo(args[0], func(ctx *rtnl.Context, ifx uint32) error {
+				return rtnl.AddMacsecRxSc(ctx, ifx, sci, true)
+			})
+		},
+	})
+
+	rxsc.AddCommand(&cobra.Command{
+		Use:   "del <name> <sci>",
+		Short: "delete receive channel",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			sci := parseSci(args[1])
+			macsecDo(args[0], func(ctx *rtnl.Context, ifx uint32) error {
+				return rtnl.DelMacsecRxSc(ctx, ifx, sci)
+			})
+		},
+	})
+
+	rxsa := &cobra.Command{
+		Use:   "rxsa",
+		Short: "receive secure association commands",
+	}
+	macsec.AddCommand(rxsa)
+
+	rxsa.AddCommand(saCmd("add <name> <sci> <an> <key-id> <key>", "add receive association", 5,
+		func(args []string, sa *rtnl.MacsecSa) {
+			sci := parseSci(args[1])
+			parseSa(sa, args[2], args[3], args[4])
+			macsecDo(args[0], func(ctx *rtnl.Context, ifx uint32) error {
+				return rtnl.AddMacsecRxSa(ctx, ifx, sci, sa)
+			})
+		}))
+
+	rxsa.AddCommand(&cobra.Command{
+		Use:   "del <name> <sci> <an>",
+		Short: "delete receive association",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			sci := parseSci(args[1])
+			an := parseAn(args[2])
+			macsecDo(args[0], func(ctx *rtnl.Context, ifx uint32) error {
+				return rtnl.DelMacsecRxSa(ctx, ifx, sci, an)
+			})
+		},
+	})
+
+}
+
+func macsecAdd(device, name string, info *rtnl.Macsec) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	dev, err := rtnl.GetLink(ctx, device)
+	if err != nil {
+		log.Fatal(err)
+	}
+	info.Link = uint32(dev.Msg.Index)
+
+	lnk := &rtnl.Link{
+		Info: &rtnl.LinkInfo{
+			Name:   name,
+			Macsec: info,
+		},
+	}
+
+	err = lnk.Add(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+// macsecDo runs f against the index of the named macsec link.
+func macsecDo(name string, f func(*rtnl.Context, uint32) error) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk, err := rtnl.GetLink(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = f(ctx, uint32(lnk.Msg.Index))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func parseSa(sa *rtnl.MacsecSa, an, keyid, key string) {
+
+	sa.An = parseAn(an)
+
+	id, err := hex.DecodeString(keyid)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(id) > rtnl.MACSEC_KEYID_LEN {
+		log.Fatal("key id too long")
+	}
+	copy(sa.KeyId[:], id)
+
+	sa.Key, err = hex.DecodeString(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func parseAn(an string) uint8 {
+
+	v, err := strconv.ParseUint(an, 10, 8)
+	if err != nil || v > 3 {
+		log.Fatal("association number must be 0-3")
+	}
+	return uint8(v)
+
+}
+
+func parseSci(sci string) uint64 {
+
+	v, err := strconv.ParseUint(sci, 16, 64)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return v
+
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"net"
 
 	"github.com/spf13/cobra"
 
@@ -16,16 +18,18 @@ func macvlanCommands(root *cobra.Command) {
 	}
 	root.AddCommand(macvlan)
 
+	var nopromisc bool
 	add := &cobra.Command{
 		Use:   "add <device> <name> <mode>",
 		Short: "add macvlan",
 		Args:  cobra.ExactArgs(3),
 		Run: func(cmd *cobra.Command, args []string) {
 
-			modMacvlan(args[0], args[1], args[2], false)
+			modMacvlan(args[0], args[1], args[2], nopromisc, false)
 
 		},
 	}
+	add.Flags().BoolVar(&nopromisc, "nopromisc", false, "do not put the lower device in promiscuous mode")
 	macvlan.AddCommand(add)
 
 	del := &cobra.Command{
@@ -34,15 +38,129 @@ func macvlanCommands(root *cobra.Command) {
 		Args:  cobra.ExactArgs(3),
 		Run: func(cmd *cobra.Command, args []string) {
 
-			modMacvlan(args[0], args[1], args[2], true)
+			modMacvlan(args[0], args[1], args[2], false, true)
 
 		},
 	}
 	macvlan.AddCommand(del)
 
+	source := &cobra.Command{
+		Use:   "source",
+		Short: "source mode address commands",
+	}
+	macvlan.AddCommand(source)
+
+	sourceAdd := &cobra.Command{
+		Use:   "add <name> <mac>",
+		Short: "allow a source address",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			macvlanSource(args[0], rtnl.MACVLAN_MACADDR_ADD, args[1:])
+		},
+	}
+	source.AddCommand(sourceAdd)
+
+	sourceDel := &cobra.Command{
+		Use:   "del <name> <mac>",
+		Short: "disallow a source address",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			macvlanSource(args[0], rtnl.MACVLAN_MACADDR_DEL, args[1:])
+		},
+	}
+	source.AddCommand(sourceDel)
+
+	sourceFlush := &cobra.Command{
+		Use:   "flush <name>",
+		Short: "disallow all source addresses",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			macvlanSource(args[0], rtnl.MACVLAN_MACADDR_FLUSH, nil)
+		},
+	}
+	source.AddCommand(sourceFlush)
+
+	sourceSet := &cobra.Command{
+		Use:   "set <name> <mac>...",
+		Short: "replace the allowed source addresses",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			macvlanSource(args[0], rtnl.MACVLAN_MACADDR_SET, args[1:])
+		},
+	}
+	source.AddCommand(sourceSet)
+
+	sourceShow := &cobra.Command{
+		Use:   "show <name>",
+		Short: "show the allowed source addresses",
+		Args:  cobra.ExactArgs(1),
+		Run:   func(cmd *cobra.Command, args []string) { macvlanSourceShow(args[0]) },
+	}
+	source.AddCommand(sourceShow)
+
+}
+
+func macvlanSource(name string, mode uint32, addrs []string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	var macs []net.HardwareAddr
+	for _, a := range addrs {
+		mac, err := net.ParseMAC(a)
+		if err != nil {
+			log.Fatal(err)
+		}
+		macs = append(macs, mac)
+	}
+
+	lnk, err := rtnl.GetLink(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch mode {
+	case rtnl.MACVLAN_MACADDR_ADD:
+		err = lnk.AddMacvlanSource(ctx, macs[0])
+	case rtnl.MACVLAN_MACADDR_DEL:
+		err = lnk.DelMacvlanSource(ctx, macs[0])
+	case rtnl.MACVLAN_MACADDR_FLUSH:
+		err = lnk.FlushMacvlanSources(ctx)
+	case rtnl.MACVLAN_MACADDR_SET:
+		err = lnk.SetMacvlanSources(ctx, macs)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
 }
 
-func modMacvlan(dev, name, mode string, del bool) {
+func macvlanSourceShow(name string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk, err := rtnl.GetLink(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if lnk.Info.Macvlan == nil {
+		log.Fatalf("%s is not a macvlan", name)
+	}
+
+	for _, mac := range lnk.Info.Macvlan.SourceMacs {
+		fmt.Println(mac)
+	}
+
+}
+
+func modMacvlan(dev, name, mode string, nopromisc, del bool) {
 
 	ctx, err := rtnl.OpenDefaultContext()
 	if err != nil {
@@ -65,6 +183,9 @@ func modMacvlan(dev, name, mode string, del bool) {
 		Mode: m,
 		Link: uint32(target.Msg.Index),
 	}
+	if nopromisc {
+		link.Info.Macvlan.Flags |= rtnl.MACVLAN_FLAG_NOPROMISC
+	}
 
 	if del {
 		err = link.Del(ctx)
@@ -38,10 +38,15 @@ func main() {
 	root.AddCommand(version)
 
 	linkCommands(root)
+	addrCommands(root)
 	ruleCommands(root)
 	routeCommands(root)
 	vrfCommands(root)
 	macvlanCommands(root)
+	bridgeCommands(root)
+	wgCommands(root)
+	macsecCommands(root)
+	netconfCommands(root)
 
 	root.Execute()
 
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
+
+	"gitlab.com/mergetb/tech/rtnl"
+)
+
+func netconfCommands(root *cobra.Command) {
+
+	var family string
+	netconf := &cobra.Command{
+		Use:   "netconf [device]",
+		Short: "show forwarding configuration",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dev := ""
+			if len(args) > 0 {
+				dev = args[0]
+			}
+			doNetconf(parseNetconfFamily(family), dev)
+		},
+	}
+	netconf.Flags().StringVarP(&family, "family", "f", "inet", "address family (inet|inet6|mpls)")
+	root.AddCommand(netconf)
+
+	monitor := &cobra.Command{
+		Use:   "monitor",
+		Short: "show link and netconf changes as they happen",
+		Args:  cobra.NoArgs,
+		Run:   func(cmd *cobra.Command, args []string) { doMonitor() },
+	}
+	root.AddCommand(monitor)
+
+}
+
+func doNetconf(family uint8, dev string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	var confs []*rtnl.Netconf
+	if dev != "" {
+		var index int32
+		switch dev {
+		case "all":
+			index = rtnl.NETCONFA_IFINDEX_ALL
+		case "default":
+			index = rtnl.NETCONFA_IFINDEX_DEFAULT
+		default:
+			lnk, err := rtnl.GetLink(ctx, dev)
+			if err != nil {
+				log.Fatal(err)
+			}
+			index = lnk.Msg.Index
+		}
+		nc, err := rtnl.ReadNetconf(ctx, family, index)
+		if err != nil {
+			log.Fatal(err)
+		}
+		confs = append(confs, nc)
+	} else {
+		confs, err = rtnl.ReadNetconfs(ctx, family)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, nc := range confs {
+		fmt.Fprintf(tw, "%s\t%s\n", netconfDev(ctx, nc.Ifindex), netconfString(nc))
+	}
+	tw.Flush()
+
+}
+
+func doMonitor() {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	events := make(chan rtnl.Event)
+	go func() {
+		err := rtnl.Watch(ctx, []uint32{
+			rtnl.RTNLGRP_LINK,
+			rtnl.RTNLGRP_IPV4_NETCONF,
+			rtnl.RTNLGRP_IPV6_NETCONF,
+			rtnl.RTNLGRP_MPLS_NETCONF,
+		}, events, nil)
+		log.Fatal(err)
+	}()
+
+	for ev := range events {
+
+		op := "new"
+		if ev.Type == unix.RTM_DELLINK || ev.Type == unix.RTM_DELNETCONF {
+			op = "del"
+		}
+
+		if ev.Link != nil {
+			fmt.Printf("%s link %s %s\n",
+				op, ev.Link.Info.Name, ev.Link.Info.Type())
+		}
+		if ev.Netconf != nil {
+			fmt.Printf("%s netconf %s %s\n",
+				op, netconfDev(ctx, ev.Netconf.Ifindex), netconfString(ev.Netconf))
+		}
+
+	}
+
+}
+
+func netconfDev(ctx *rtnl.Context, index int32) string {
+
+	switch index {
+	case rtnl.NETCONFA_IFINDEX_ALL:
+		return "all"
+	case rtnl.NETCONFA_IFINDEX_DEFAULT:
+		return "default"
+	}
+
+	lnk, err := rtnl.GetLinkByIndex(ctx, index)
+	if err != nil {
+		return fmt.Sprintf("if%d", index)
+	}
+	return lnk.Info.Name
+
+}
+
+func netconfString(nc *rtnl.Netconf) string {
+
+	s := ""
+	for _, x := range []struct {
+		name  string
+		value *int32
+	}{
+		{"forwarding", nc.Forwarding},
+		{"rp_filter", nc.RpFilter},
+		{"mc_forwarding", nc.McForwarding},
+		{"proxy_neigh", nc.ProxyNeigh},
+		{"ignore_routes_with_linkdown", nc.IgnoreRoutesWithLinkdown},
+		{"input", nc.Input},
+		{"bc_forwarding", nc.BcForwarding},
+	} {
+		if x.value != nil {
+			s += fmt.Sprintf("%s=%d ", x.name, *x.value)
+		}
+	}
+	return s
+
+}
+
+func parseNetconfFamily(family string) uint8 {
+
+	switch family {
+	case "inet":
+		return unix.AF_INET
+	case "inet6":
+		return unix.AF_INET6
+	case "mpls":
+		return unix.AF_MPLS
+	}
+
+	log.Fatalf("unknown family %s", family)
+	return 0
+
+}
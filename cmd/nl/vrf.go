@@ -1,10 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
 
 	"gitlab.com/mergetb/tech/rtnl"
 )
@@ -51,6 +54,30 @@ func vrfCommands(root *cobra.Command) {
 	}
 	vrf.AddCommand(del)
 
+	enslave := &cobra.Command{
+		Use:   "enslave <vrf> <interface>",
+		Short: "enslave an interface to a vrf",
+		Args:  cobra.ExactArgs(2),
+		Run:   func(cmd *cobra.Command, args []string) { enslaveVrf(args[0], args[1]) },
+	}
+	vrf.AddCommand(enslave)
+
+	release := &cobra.Command{
+		Use:   "release <interface>",
+		Short: "release an interface from its vrf",
+		Args:  cobra.ExactArgs(1),
+		Run:   func(cmd *cobra.Command, args []string) { releaseVrf(args[0]) },
+	}
+	vrf.AddCommand(release)
+
+	show := &cobra.Command{
+		Use:   "show <vrf>",
+		Short: "show vrf members, their addresses and the vrf routes",
+		Args:  cobra.ExactArgs(1),
+		Run:   func(cmd *cobra.Command, args []string) { showVrf(args[0]) },
+	}
+	vrf.AddCommand(show)
+
 }
 
 func modVrf(name string, table int, del bool) {
@@ -76,4 +103,111 @@ func modVrf(name string, table int, del bool) {
 		log.Fatal(err)
 	}
 
+	if !del {
+		for _, family := range []uint8{unix.AF_INET, unix.AF_INET6} {
+			err = rtnl.EnsureL3mdevRule(ctx, family)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+}
+
+func enslaveVrf(vrf, iface string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	err = rtnl.VrfEnslave(ctx, vrf, iface)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func releaseVrf(iface string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	err = rtnl.VrfRelease(ctx, iface)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func showVrf(name string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	v, err := rtnl.GetVrf(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	members, err := rtnl.VrfMembers(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("vrf %s table %d\n\n", name, v.Info.Vrf.Table)
+
+	fmt.Fprintf(tw, "%s\t%s\n", "member", "addrs")
+	for _, m := range members {
+
+		addrs, err := m.Addrs(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var addrList []string
+		for _, x := range addrs {
+			addrList = append(addrList, x.Info.Address.String())
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\n", m.Info.Name, strings.Join(addrList, " "))
+
+	}
+	tw.Flush()
+
+	fmt.Println()
+
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+		"dest",
+		"gateway",
+		"prefsrc",
+		"oif",
+		"priority",
+	)
+	for _, family := range []uint8{unix.AF_INET, unix.AF_INET6} {
+
+		routes, err := rtnl.VrfRoutes(ctx, name, family)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, route := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n",
+				routeLabel(route.Dest, route.Hdr.Dst_len),
+				targetLabel(route.Gateway),
+				targetLabel(route.PrefSrc),
+				ifLabel(ctx, route.Oif),
+				route.Priority,
+			)
+		}
+
+	}
+	tw.Flush()
+
 }
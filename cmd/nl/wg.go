@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/mergetb/tech/rtnl"
+)
+
+func wgCommands(root *cobra.Command) {
+
+	wg := &cobra.Command{
+		Use:   "wg",
+		Short: "wireguard command family",
+	}
+	root.AddCommand(wg)
+
+	show := &cobra.Command{
+		Use:   "show <name>",
+		Short: "show wireguard device configuration and peers",
+		Args:  cobra.ExactArgs(1),
+		Run:   func(cmd *cobra.Command, args []string) { wgShow(args[0]) },
+	}
+	wg.AddCommand(show)
+
+	var (
+		privateKey   string
+		listenPort   uint16
+		fwmark       uint32
+		peer         string
+		presharedKey string
+		endpoint     string
+		allowedIPs   []string
+		keepalive    uint16
+		remove       bool
+	)
+	set := &cobra.Command{
+		Use:   "set <name>",
+		Short: "set wireguard device configuration and peers",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			dev := &rtnl.WireguardDevice{Name: args[0]}
+			flags := cmd.Flags()
+
+			if flags.Changed("private-key") {
+				key := readWgKey(privateKey)
+				dev.PrivateKey = &key
+			}
+			if flags.Changed("listen-port") {
+				dev.ListenPort = &listenPort
+			}
+			if flags.Changed("fwmark") {
+				dev.Fwmark = &fwmark
+			}
+
+			if peer != "" {
+				p, err := wgPeer(peer, remove)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if flags.Changed("preshared-key") {
+					key := readWgKey(presharedKey)
+					p.PresharedKey = &key
+				}
+				if endpoint != "" {
+					p.Endpoint, err = net.ResolveUDPAddr("udp", endpoint)
+					if err != nil {
+						log.Fatal(err)
+					}
+				}
+				if flags.Changed("allowed-ips") {
+					p.ReplaceAllowedIPs = true
+					p.AllowedIPs = []net.IPNet{}
+					for _, x := range allowedIPs {
+						_, ipn, err := net.ParseCIDR(x)
+						if err != nil {
+							log.Fatal(err)
+						}
+						p.AllowedIPs = append(p.AllowedIPs, *ipn)
+					}
+				}
+				if flags.Changed("keepalive") {
+					p.KeepaliveInterval = &keepalive
+				}
+				dev.Peers = append(dev.Peers, *p)
+			}
+
+			wgSet(dev)
+
+		},
+	}
+	set.Flags().StringVar(&privateKey, "private-key", "", "file holding the private key")
+	set.Flags().Uint16VarP(&listenPort, "listen-port", "p", 0, "listen port")
+	set.Flags().Uint32Var(&fwmark, "fwmark", 0, "firewall mark of outgoing packets")
+	set.Flags().StringVar(&peer, "peer", "", "public key of the peer to configure")
+	set.Flags().StringVar(&presharedKey, "preshared-key", "", "file holding the peer preshared key")
+	set.Flags().StringVarP(&endpoint, "endpoint", "e", "", "peer endpoint <host>:<port>")
+	set.Flags().StringSliceVarP(&allowedIPs, "allowed-ips", "a", nil, "peer allowed ips")
+	set.Flags().Uint16VarP(&keepalive, "keepalive", "k", 0, "peer persistent keepalive seconds")
+	set.Flags().BoolVarP(&remove, "remove", "r", false, "remove the peer")
+	wg.AddCommand(set)
+
+}
+
+func wgShow(name string) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	dev, err := rtnl.ReadWireguardDevice(ctx, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintf(tw, "%s\t%s\n", "interface", dev.Name)
+	fmt.Fprintf(tw, "%s\t%s\n", "public-key", dev.PublicKey)
+	if dev.ListenPort != nil {
+		fmt.Fprintf(tw, "%s\t%d\n", "listen-port", *dev.ListenPort)
+	}
+	if dev.Fwmark != nil && *dev.Fwmark != 0 {
+		fmt.Fprintf(tw, "%s\t%#x\n", "fwmark", *dev.Fwmark)
+	}
+	tw.Flush()
+
+	for _, p := range dev.Peers {
+
+		fmt.Println()
+		fmt.Fprintf(tw, "%s\t%s\n", "peer", p.PublicKey)
+		if p.Endpoint != nil {
+			fmt.Fprintf(tw, "%s\t%s\n", "endpoint", p.Endpoint)
+		}
+
+		var ips []string
+		for _, x := range p.AllowedIPs {
+			ips = append(ips, x.String())
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", "allowed-ips", strings.Join(ips, ","))
+
+		if !p.LastHandshake.IsZero() {
+			fmt.Fprintf(tw, "%s\t%s ago\n", "latest-handshake",
+				time.Since(p.LastHandshake).Round(time.Second))
+		}
+		fmt.Fprintf(tw, "%s\t%d received, %d sent\n", "transfer", p.RxBytes, p.TxBytes)
+		if p.KeepaliveInterval != nil && *p.KeepaliveInterval != 0 {
+			fmt.Fprintf(tw, "%s\t%ds\n", "keepalive", *p.KeepaliveInterval)
+		}
+		tw.Flush()
+
+	}
+
+}
+
+func wgSet(dev *rtnl.WireguardDevice) {
+
+	ctx, err := rtnl.OpenDefaultContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ctx.Close()
+
+	err = dev.Set(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+func wgPeer(key string, remove bool) (*rtnl.WireguardPeer, error) {
+
+	pub, err := rtnl.ParseWireguardKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rtnl.WireguardPeer{PublicKey: pub, Remove: remove}, nil
+
+}
+
+func readWgKey(file string) rtnl.WireguardKey {
+
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	key, err := rtnl.ParseWireguardKey(strings.TrimSpace(string(buf)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return key
+
+}
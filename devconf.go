@@ -0,0 +1,469 @@
+package rtnl
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// inet af spec attribute types
+const (
+	IFLA_INET_UNSPEC uint16 = iota
+	IFLA_INET_CONF
+)
+
+// inet6 af spec attribute types
+const (
+	IFLA_INET6_UNSPEC uint16 = iota
+	IFLA_INET6_FLAGS
+	IFLA_INET6_CONF
+	IFLA_INET6_STATS
+	IFLA_INET6_MCAST
+	IFLA_INET6_CACHEINFO
+	IFLA_INET6_ICMP6STATS
+	IFLA_INET6_TOKEN
+	IFLA_INET6_ADDR_GEN_MODE
+)
+
+// Ipv4Devconf aliases ipv4 device configuration settings in a type safe way
+type Ipv4Devconf uint16
+
+const (
+	IPV4_DEVCONF_FORWARDING Ipv4Devconf = iota + 1
+	IPV4_DEVCONF_MC_FORWARDING
+	IPV4_DEVCONF_PROXY_ARP
+	IPV4_DEVCONF_ACCEPT_REDIRECTS
+	IPV4_DEVCONF_SECURE_REDIRECTS
+	IPV4_DEVCONF_SEND_REDIRECTS
+	IPV4_DEVCONF_SHARED_MEDIA
+	IPV4_DEVCONF_RP_FILTER
+	IPV4_DEVCONF_ACCEPT_SOURCE_ROUTE
+	IPV4_DEVCONF_BOOTP_RELAY
+	IPV4_DEVCONF_LOG_MARTIANS
+	IPV4_DEVCONF_TAG
+	IPV4_DEVCONF_ARPFILTER
+	IPV4_DEVCONF_MEDIUM_ID
+	IPV4_DEVCONF_NOXFRM
+	IPV4_DEVCONF_NOPOLICY
+	IPV4_DEVCONF_FORCE_IGMP_VERSION
+	IPV4_DEVCONF_ARP_ANNOUNCE
+	IPV4_DEVCONF_ARP_IGNORE
+	IPV4_DEVCONF_PROMOTE_SECONDARIES
+	IPV4_DEVCONF_ARP_ACCEPT
+	IPV4_DEVCONF_ARP_NOTIFY
+	IPV4_DEVCONF_ACCEPT_LOCAL
+	IPV4_DEVCONF_SRC_VMARK
+	IPV4_DEVCONF_PROXY_ARP_PVLAN
+	IPV4_DEVCONF_ROUTE_LOCALNET
+	IPV4_DEVCONF_IGMPV2_UNSOLICITED_REPORT_INTERVAL
+	IPV4_DEVCONF_IGMPV3_UNSOLICITED_REPORT_INTERVAL
+	IPV4_DEVCONF_IGNORE_ROUTES_WITH_LINKDOWN
+	IPV4_DEVCONF_DROP_UNICAST_IN_L2_MULTICAST
+	IPV4_DEVCONF_DROP_GRATUITOUS_ARP
+	IPV4_DEVCONF_BC_FORWARDING
+)
+
+// Ipv6Devconf aliases ipv6 device configuration settings in a type safe way
+type Ipv6Devconf uint16
+
+const (
+	DEVCONF_FORWARDING Ipv6Devconf = iota
+	DEVCONF_HOPLIMIT
+	DEVCONF_MTU6
+	DEVCONF_ACCEPT_RA
+	DEVCONF_ACCEPT_REDIRECTS
+	DEVCONF_AUTOCONF
+	DEVCONF_DAD_TRANSMITS
+	DEVCONF_RTR_SOLICITS
+	DEVCONF_RTR_SOLICIT_INTERVAL
+	DEVCONF_RTR_SOLICIT_DELAY
+	DEVCONF_USE_TEMPADDR
+	DEVCONF_TEMP_VALID_LFT
+	DEVCONF_TEMP_PREFERED_LFT
+	DEVCONF_REGEN_MAX_RETRY
+	DEVCONF_MAX_DESYNC_FACTOR
+	DEVCONF_MAX_ADDRESSES
+	DEVCONF_FORCE_MLD_VERSION
+	DEVCONF_ACCEPT_RA_DEFRTR
+	DEVCONF_ACCEPT_RA_PINFO
+	DEVCONF_ACCEPT_RA_RTR_PREF
+	DEVCONF_RTR_PROBE_INTERVAL
+	DEVCONF_ACCEPT_RA_RT_INFO_MAX_PLEN
+	DEVCONF_PROXY_NDP
+	DEVCONF_OPTIMISTIC_DAD
+	DEVCONF_ACCEPT_SOURCE_ROUTE
+	DEVCONF_MC_FORWARDING
+	DEVCONF_DISABLE_IPV6
+	DEVCONF_ACCEPT_DAD
+	DEVCONF_FORCE_TLLAO
+	DEVCONF_NDISC_NOTIFY
+	DEVCONF_MLDV1_UNSOLICITED_REPORT_INTERVAL
+	DEVCONF_MLDV2_UNSOLICITED_REPORT_INTERVAL
+	DEVCONF_SUPPRESS_FRAG_NDISC
+	DEVCONF_ACCEPT_RA_FROM_LOCAL
+	DEVCONF_USE_OPTIMISTIC
+	DEVCONF_ACCEPT_RA_MTU
+	DEVCONF_STABLE_SECRET
+	DEVCONF_USE_OIF_ADDRS_ONLY
+	DEVCONF_ACCEPT_RA_MIN_HOP_LIMIT
+	DEVCONF_IGNORE_ROUTES_WITH_LINKDOWN
+	DEVCONF_DROP_UNICAST_IN_L2_MULTICAST
+	DEVCONF_DROP_UNSOLICITED_NA
+	DEVCONF_KEEP_ADDR_ON_DOWN
+	DEVCONF_RTR_SOLICIT_MAX_INTERVAL
+	DEVCONF_SEG6_ENABLED
+	DEVCONF_SEG6_REQUIRE_HMAC
+	DEVCONF_ENHANCED_DAD
+	DEVCONF_ADDR_GEN_MODE
+	DEVCONF_DISABLE_POLICY
+	DEVCONF_ACCEPT_RA_RT_INFO_MIN_PLEN
+	DEVCONF_NDISC_TCLASS
+)
+
+// Inet6AddrGenMode aliases ipv6 link local address generation modes in a type
+// safe way
+type Inet6AddrGenMode uint8
+
+const (
+	IN6_ADDR_GEN_MODE_EUI64 Inet6AddrGenMode = iota
+	IN6_ADDR_GEN_MODE_NONE
+	IN6_ADDR_GEN_MODE_STABLE_PRIVACY
+	IN6_ADDR_GEN_MODE_RANDOM
+)
+
+// the sysctl names of the ipv4 settings, indexed by setting
+var ipv4DevconfNames = []string{
+	"",
+	"forwarding",
+	"mc_forwarding",
+	"proxy_arp",
+	"accept_redirects",
+	"secure_redirects",
+	"send_redirects",
+	"shared_media",
+	"rp_filter",
+	"accept_source_route",
+	"bootp_relay",
+	"log_martians",
+	"tag",
+	"arp_filter",
+	"medium_id",
+	"disable_xfrm",
+	"disable_policy",
+	"force_igmp_version",
+	"arp_announce",
+	"arp_ignore",
+	"promote_secondaries",
+	"arp_accept",
+	"arp_notify",
+	"accept_local",
+	"src_valid_mark",
+	"proxy_arp_pvlan",
+	"route_localnet",
+	"igmpv2_unsolicited_report_interval",
+	"igmpv3_unsolicited_report_interval",
+	"ignore_routes_with_linkdown",
+	"drop_unicast_in_l2_multicast",
+	"drop_gratuitous_arp",
+	"bc_forwarding",
+}
+
+// the sysctl names of the ipv6 settings, indexed by setting
+var ipv6DevconfNames = []string{
+	"forwarding",
+	"hop_limit",
+	"mtu",
+	"accept_ra",
+	"accept_redirects",
+	"autoconf",
+	"dad_transmits",
+	"router_solicitations",
+	"router_solicitation_interval",
+	"router_solicitation_delay",
+	"use_tempaddr",
+	"temp_valid_lft",
+	"temp_prefered_lft",
+	"regen_max_retry",
+	"max_desync_factor",
+	"max_addresses",
+	"force_mld_version",
+	"accept_ra_defrtr",
+	"accept_ra_pinfo",
+	"accept_ra_rtr_pref",
+	"router_probe_interval",
+	"accept_ra_rt_info_max_plen",
+	"proxy_ndp",
+	"optimistic_dad",
+	"accept_source_route",
+	"mc_forwarding",
+	"disable_ipv6",
+	"accept_dad",
+	"force_tllao",
+	"ndisc_notify",
+	"mldv1_unsolicited_report_interval",
+	"mldv2_unsolicited_report_interval",
+	"suppress_frag_ndisc",
+	"accept_ra_from_local",
+	"use_optimistic",
+	"accept_ra_mtu",
+	"stable_secret",
+	"use_oif_addrs_only",
+	"accept_ra_min_hop_limit",
+	"ignore_routes_with_linkdown",
+	"drop_unicast_in_l2_multicast",
+	"drop_unsolicited_na",
+	"keep_addr_on_down",
+	"router_solicitation_max_interval",
+	"seg6_enabled",
+	"seg6_require_hmac",
+	"enhanced_dad",
+	"addr_gen_mode",
+	"disable_policy",
+	"accept_ra_rt_info_min_plen",
+	"ndisc_tclass",
+}
+
+// Inet6Info holds the ipv6 state of a link that is not part of the device
+// configuration.
+type Inet6Info struct {
+	Flags       uint32
+	Token       net.IP
+	AddrGenMode Inet6AddrGenMode
+}
+
+// Decoding ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// unmarshalInetAfSpec reads the per address family attributes the kernel
+// reports for links in IFLA_AF_SPEC.
+func (li *LinkInfo) unmarshalInetAfSpec(buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+
+		case unix.AF_INET:
+			err := forEachNested(ad.Bytes(), IFLA_INET_CONF, func(b []byte) error {
+				li.Inet4Conf = make(map[Ipv4Devconf]uint32)
+				for i := 0; i+4 <= len(b); i += 4 {
+					li.Inet4Conf[Ipv4Devconf(i/4+1)] = nlenc.Uint32(b[i : i+4])
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+		case unix.AF_INET6:
+			err := li.unmarshalInet6(ad.Bytes())
+			if err != nil {
+				return err
+			}
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+func (li *LinkInfo) unmarshalInet6(buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return err
+	}
+
+	li.Inet6 = &Inet6Info{}
+
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+
+		case IFLA_INET6_FLAGS:
+			li.Inet6.Flags = ad.Uint32()
+
+		case IFLA_INET6_CONF:
+			b := ad.Bytes()
+			li.Inet6Conf = make(map[Ipv6Devconf]int32)
+			for i := 0; i+4 <= len(b); i += 4 {
+				li.Inet6Conf[Ipv6Devconf(i/4)] = nlenc.Int32(b[i : i+4])
+			}
+
+		case IFLA_INET6_TOKEN:
+			li.Inet6.Token = net.IP(ad.Bytes())
+
+		case IFLA_INET6_ADDR_GEN_MODE:
+			li.Inet6.AddrGenMode = Inet6AddrGenMode(ad.Uint8())
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+// Setters ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// SetInet4Conf changes the provided ipv4 settings of the link. The kernel only
+// takes ipv4 device configuration over netlink, ipv6 settings other than the
+// address generation mode and token are not settable this way.
+func (l *Link) SetInet4Conf(ctx *Context, conf map[Ipv4Devconf]uint32) error {
+
+	return l.setInetAfSpec(ctx, unix.AF_INET, func(ae *netlink.AttributeEncoder) {
+		ae.Do(IFLA_INET_CONF, func() ([]byte, error) {
+			ae1 := netlink.NewAttributeEncoder()
+			for k, v := range conf {
+				ae1.Uint32(uint16(k), v)
+			}
+			return ae1.Encode()
+		})
+	})
+
+}
+
+// SetInet6AddrGenMode changes how the link local address of the link is
+// generated.
+func (l *Link) SetInet6AddrGenMode(ctx *Context, mode Inet6AddrGenMode) error {
+
+	return l.setInetAfSpec(ctx, unix.AF_INET6, func(ae *netlink.AttributeEncoder) {
+		ae.Uint8(IFLA_INET6_ADDR_GEN_MODE, uint8(mode))
+	})
+
+}
+
+// SetInet6Token sets the interface identifier the link uses for addresses it
+// configures from router advertisements.
+func (l *Link) SetInet6Token(ctx *Context, token net.IP) error {
+
+	if token.To16() == nil {
+		return fmt.Errorf("token must be an ipv6 address")
+	}
+
+	return l.setInetAfSpec(ctx, unix.AF_INET6, func(ae *netlink.AttributeEncoder) {
+		ae.Bytes(IFLA_INET6_TOKEN, token.To16())
+	})
+
+}
+
+func (l *Link) setInetAfSpec(
+	ctx *Context, family uint16, f func(*netlink.AttributeEncoder),
+) error {
+
+	if l.Msg.Index == 0 {
+		return fmt.Errorf("link index must be set")
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_AF_SPEC, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Do(family, func() ([]byte, error) {
+			ae2 := netlink.NewAttributeEncoder()
+			f(ae2)
+			return ae2.Encode()
+		})
+		return ae1.Encode()
+
+	})
+	attrs, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode af spec attributes")
+		return err
+	}
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.RTM_SETLINK),
+			Flags: netlink.Request | netlink.Acknowledge,
+		},
+		Data: append(IfInfomsgBytes(unix.IfInfomsg{Index: l.Msg.Index}), attrs...),
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
+
+}
+
+// Names ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+func (c Ipv4Devconf) String() string {
+
+	if int(c) > 0 && int(c) < len(ipv4DevconfNames) {
+		return ipv4DevconfNames[c]
+	}
+	return fmt.Sprintf("devconf%d", c)
+
+}
+
+func (c Ipv6Devconf) String() string {
+
+	if int(c) < len(ipv6DevconfNames) {
+		return ipv6DevconfNames[c]
+	}
+	return fmt.Sprintf("devconf%d", c)
+
+}
+
+// ParseIpv4Devconf returns the ipv4 setting with the provided sysctl name.
+func ParseIpv4Devconf(name string) (Ipv4Devconf, error) {
+
+	for i, x := range ipv4DevconfNames {
+		if i > 0 && x == name {
+			return Ipv4Devconf(i), nil
+		}
+	}
+	return 0, fmt.Errorf("undefined ipv4 setting %s", name)
+
+}
+
+// ParseIpv6Devconf returns the ipv6 setting with the provided sysctl name.
+func ParseIpv6Devconf(name string) (Ipv6Devconf, error) {
+
+	for i, x := range ipv6DevconfNames {
+		if x == name {
+			return Ipv6Devconf(i), nil
+		}
+	}
+	return 0, fmt.Errorf("undefined ipv6 setting %s", name)
+
+}
+
+func (m Inet6AddrGenMode) String() string {
+
+	switch m {
+	case IN6_ADDR_GEN_MODE_EUI64:
+		return "eui64"
+	case IN6_ADDR_GEN_MODE_NONE:
+		return "none"
+	case IN6_ADDR_GEN_MODE_STABLE_PRIVACY:
+		return "stable-privacy"
+	case IN6_ADDR_GEN_MODE_RANDOM:
+		return "random"
+	default:
+		return "unknown"
+	}
+
+}
+
+func ParseInet6AddrGenMode(mode string) (Inet6AddrGenMode, error) {
+
+	switch mode {
+	case "eui64":
+		return IN6_ADDR_GEN_MODE_EUI64, nil
+	case "none":
+		return IN6_ADDR_GEN_MODE_NONE, nil
+	case "stable-privacy":
+		return IN6_ADDR_GEN_MODE_STABLE_PRIVACY, nil
+	case "random":
+		return IN6_ADDR_GEN_MODE_RANDOM, nil
+	}
+
+	return 0, fmt.Errorf("undefined addr gen mode")
+
+}
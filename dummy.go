@@ -0,0 +1,40 @@
+package rtnl
+
+import (
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Dummy encapsulates dummy links, which drop all traffic and are useful
+// for anchoring addresses
+type Dummy struct {
+}
+
+func (t *Dummy) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte("dummy"))
+
+		return ae1.Encode()
+
+	})
+
+	return ae.Encode()
+
+}
+
+// Unmarshal is a no-op, dummy links carry no kind specific attributes.
+func (t *Dummy) Unmarshal(ctx *Context, buf []byte) error {
+
+	return nil
+
+}
+
+func (t *Dummy) Resolve(ctx *Context) error {
+
+	return nil
+
+}
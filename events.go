@@ -0,0 +1,185 @@
+package rtnl
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// rtnetlink multicast groups
+const (
+	RTNLGRP_NONE uint32 = iota
+	RTNLGRP_LINK
+	RTNLGRP_NOTIFY
+	RTNLGRP_NEIGH
+	RTNLGRP_TC
+	RTNLGRP_IPV4_IFADDR
+	RTNLGRP_IPV4_MROUTE
+	RTNLGRP_IPV4_ROUTE
+	RTNLGRP_IPV4_RULE
+	RTNLGRP_IPV6_IFADDR
+	RTNLGRP_IPV6_MROUTE
+	RTNLGRP_IPV6_ROUTE
+	RTNLGRP_IPV6_IFINFO
+	RTNLGRP_DECnet_IFADDR
+	RTNLGRP_NOP2
+	RTNLGRP_DECnet_ROUTE
+	RTNLGRP_DECnet_RULE
+	RTNLGRP_NOP4
+	RTNLGRP_IPV6_PREFIX
+	RTNLGRP_IPV6_RULE
+	RTNLGRP_ND_USEROPT
+	RTNLGRP_PHONET_IFADDR
+	RTNLGRP_PHONET_ROUTE
+	RTNLGRP_DCB
+	RTNLGRP_IPV4_NETCONF
+	RTNLGRP_IPV6_NETCONF
+	RTNLGRP_MDB
+	RTNLGRP_MPLS_ROUTE
+	RTNLGRP_NSID
+	RTNLGRP_MPLS_NETCONF
+)
+
+// Event is a change notification from the kernel. Type is the rtnetlink
+// message type and determines which object is set.
+type Event struct {
+	Type    uint16
+	Link    *Link
+	Netconf *Netconf
+}
+
+// Watcher receives rtnetlink notifications for a set of multicast groups.
+type Watcher struct {
+	ctx  *Context
+	conn *netlink.Conn
+}
+
+// NewWatcher joins the provided rtnetlink multicast groups in the namespace of
+// the context. Groups are joined before NewWatcher returns, so notifications
+// for changes made afterwards are not missed.
+func NewWatcher(ctx *Context, groups []uint32) (*Watcher, error) {
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	conn, err := netlink.Dial(unix.NETLINK_ROUTE, &netlink.Config{NetNS: ctx.Fd()})
+	if err != nil {
+		log.WithError(err).Error("failed to dial netlink")
+		return nil, err
+	}
+
+	for _, g := range groups {
+		err := conn.JoinGroup(g)
+		if err != nil {
+			log.WithError(err).WithField("group", g).Error("failed to join group")
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return &Watcher{ctx: ctx, conn: conn}, nil
+
+}
+
+// Close releases the watcher's netlink connection.
+func (w *Watcher) Close() error {
+
+	return w.conn.Close()
+
+}
+
+// Run delivers link and netconf notifications on events until done is closed.
+// Notifications of other kinds are dropped. Run blocks, so it is typically run
+// in its own goroutine.
+func (w *Watcher) Run(events chan<- Event, done <-chan struct{}) error {
+
+	// an expired deadline unblocks the receive below, closing the connection
+	// would wait on the pending receive
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-done:
+			w.conn.SetReadDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	for {
+
+		msgs, err := w.conn.Receive()
+		if err != nil {
+			select {
+			case <-done:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		for _, m := range msgs {
+
+			ev, ok := decodeEvent(w.ctx, m)
+			if !ok {
+				continue
+			}
+
+			select {
+			case events <- ev:
+			case <-done:
+				return nil
+			}
+
+		}
+
+	}
+
+}
+
+// Watch joins the provided rtnetlink multicast groups and delivers
+// notifications on events until done is closed, see NewWatcher and Run.
+func Watch(ctx *Context, groups []uint32, events chan<- Event, done <-chan struct{}) error {
+
+	w, err := NewWatcher(ctx, groups)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	return w.Run(events, done)
+
+}
+
+func decodeEvent(ctx *Context, m netlink.Message) (Event, bool) {
+
+	ev := Event{Type: uint16(m.Header.Type)}
+
+	switch ev.Type {
+
+	case unix.RTM_NEWLINK, unix.RTM_DELLINK:
+		ev.Link = &Link{}
+		err := ev.Link.Unmarshal(ctx, m.Data)
+		if err != nil {
+			log.WithError(err).Warning("failed to decode link event")
+			return ev, false
+		}
+
+	case unix.RTM_NEWNETCONF, unix.RTM_DELNETCONF:
+		ev.Netconf = &Netconf{}
+		err := ev.Netconf.Unmarshal(ctx, m.Data)
+		if err != nil {
+			log.WithError(err).Warning("failed to decode netconf event")
+			return ev, false
+		}
+
+	default:
+		return ev, false
+
+	}
+
+	return ev, true
+
+}
@@ -0,0 +1,209 @@
+package rtnl
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// geneve attribute types
+const (
+	IFLA_GENEVE_UNSPEC uint16 = iota
+	IFLA_GENEVE_ID
+	IFLA_GENEVE_REMOTE
+	IFLA_GENEVE_TTL
+	IFLA_GENEVE_TOS
+	IFLA_GENEVE_PORT /* destination port */
+	IFLA_GENEVE_COLLECT_METADATA
+	IFLA_GENEVE_REMOTE6
+	IFLA_GENEVE_UDP_CSUM
+	IFLA_GENEVE_UDP_ZERO_CSUM6_TX
+	IFLA_GENEVE_UDP_ZERO_CSUM6_RX
+	IFLA_GENEVE_LABEL
+	IFLA_GENEVE_TTL_INHERIT
+	IFLA_GENEVE_DF
+)
+
+// GeneveDf aliases geneve don't fragment settings in a type safe way
+type GeneveDf uint8
+
+const (
+	GENEVE_DF_UNSET GeneveDf = iota
+	GENEVE_DF_SET
+	GENEVE_DF_INHERIT
+)
+
+// Geneve encapsulates information about generic network virtualization
+// encapsulation devices.
+type Geneve struct {
+	Vni             uint32
+	Remote          net.IP
+	Ttl             uint8
+	TtlInherit      bool
+	Tos             uint8
+	DstPort         uint16
+	UdpCsum         bool
+	UdpZeroCsum6Tx  bool
+	UdpZeroCsum6Rx  bool
+	CollectMetadata bool
+	Label           uint32
+	Df              GeneveDf
+}
+
+// Marshal turns a geneve into a binary rtnetlink set of attributes.
+func (g *Geneve) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte("geneve"))
+		ae1.Do(IFLA_INFO_DATA, func() ([]byte, error) {
+
+			ae2 := netlink.NewAttributeEncoder()
+
+			// in collect metadata mode the vni and remote come from the
+			// per-packet tunnel metadata
+			if g.CollectMetadata {
+				ae2.Bytes(IFLA_GENEVE_COLLECT_METADATA, []byte{})
+			} else {
+				ae2.Uint32(IFLA_GENEVE_ID, g.Vni)
+			}
+
+			if g.Remote != nil {
+				remote := g.Remote.To4()
+				if remote != nil {
+					ae2.Bytes(IFLA_GENEVE_REMOTE, remote)
+				} else {
+					ae2.Bytes(IFLA_GENEVE_REMOTE6, g.Remote.To16())
+				}
+			}
+
+			if g.TtlInherit {
+				ae2.Uint8(IFLA_GENEVE_TTL_INHERIT, 1)
+			} else if g.Ttl != 0 {
+				ae2.Uint8(IFLA_GENEVE_TTL, g.Ttl)
+			}
+
+			if g.Tos != 0 {
+				ae2.Uint8(IFLA_GENEVE_TOS, g.Tos)
+			}
+
+			if g.DstPort != 0 {
+				ae2.Uint16(IFLA_GENEVE_PORT, htons(g.DstPort))
+			}
+
+			if g.UdpCsum {
+				ae2.Uint8(IFLA_GENEVE_UDP_CSUM, 1)
+			}
+			if g.UdpZeroCsum6Tx {
+				ae2.Uint8(IFLA_GENEVE_UDP_ZERO_CSUM6_TX, 1)
+			}
+			if g.UdpZeroCsum6Rx {
+				ae2.Uint8(IFLA_GENEVE_UDP_ZERO_CSUM6_RX, 1)
+			}
+
+			if g.Label != 0 {
+				ae2.Uint32(IFLA_GENEVE_LABEL, htonl(g.Label))
+			}
+
+			if g.Df != GENEVE_DF_UNSET {
+				ae2.Uint8(IFLA_GENEVE_DF, uint8(g.Df))
+			}
+
+			return ae2.Encode()
+
+		})
+
+		return ae1.Encode()
+
+	})
+	attrbuf, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode geneve attributes")
+		return nil, err
+	}
+
+	return attrbuf, nil
+
+}
+
+// Unmarshal reads a geneve from a binary set of attributes.
+func (g *Geneve) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create geneve attribute decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_GENEVE_ID:
+			g.Vni = ad.Uint32()
+
+		case IFLA_GENEVE_REMOTE, IFLA_GENEVE_REMOTE6:
+			g.Remote = net.IP(ad.Bytes())
+
+		case IFLA_GENEVE_TTL:
+			g.Ttl = ad.Uint8()
+
+		case IFLA_GENEVE_TTL_INHERIT:
+			g.TtlInherit = ad.Uint8() != 0
+
+		case IFLA_GENEVE_TOS:
+			g.Tos = ad.Uint8()
+
+		case IFLA_GENEVE_PORT:
+			g.DstPort = ntohs(ad.Uint16())
+
+		case IFLA_GENEVE_UDP_CSUM:
+			g.UdpCsum = ad.Uint8() != 0
+
+		case IFLA_GENEVE_UDP_ZERO_CSUM6_TX:
+			g.UdpZeroCsum6Tx = ad.Uint8() != 0
+
+		case IFLA_GENEVE_UDP_ZERO_CSUM6_RX:
+			g.UdpZeroCsum6Rx = ad.Uint8() != 0
+
+		case IFLA_GENEVE_COLLECT_METADATA:
+			g.CollectMetadata = true
+
+		case IFLA_GENEVE_LABEL:
+			g.Label = ntohl(ad.Uint32())
+
+		case IFLA_GENEVE_DF:
+			g.Df = GeneveDf(ad.Uint8())
+
+		}
+	}
+
+	return nil
+
+}
+
+// Resolve handle attributes
+func (g *Geneve) Resolve(ctx *Context) error {
+
+	return nil
+
+}
+
+func ParseGeneveDf(df string) (GeneveDf, error) {
+
+	switch df {
+	case "unset":
+		return GENEVE_DF_UNSET, nil
+	case "set":
+		return GENEVE_DF_SET, nil
+	case "inherit":
+		return GENEVE_DF_INHERIT, nil
+	}
+
+	return 0, fmt.Errorf("undefined geneve df mode")
+
+}
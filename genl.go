@@ -0,0 +1,235 @@
+package rtnl
+
+import (
+	"fmt"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	genlHdrLen = 4
+)
+
+// generic netlink controller family id
+const GENL_ID_CTRL = 0x10
+
+// generic netlink controller commands
+const (
+	CTRL_CMD_UNSPEC uint8 = iota
+	CTRL_CMD_NEWFAMILY
+	CTRL_CMD_DELFAMILY
+	CTRL_CMD_GETFAMILY
+)
+
+// generic netlink controller attribute types
+const (
+	CTRL_ATTR_UNSPEC uint16 = iota
+	CTRL_ATTR_FAMILY_ID
+	CTRL_ATTR_FAMILY_NAME
+	CTRL_ATTR_VERSION
+	CTRL_ATTR_HDRSIZE
+	CTRL_ATTR_MAXATTR
+	CTRL_ATTR_OPS
+	CTRL_ATTR_MCAST_GROUPS
+)
+
+// generic netlink controller multicast group attribute types
+const (
+	CTRL_ATTR_MCAST_GRP_UNSPEC uint16 = iota
+	CTRL_ATTR_MCAST_GRP_NAME
+	CTRL_ATTR_MCAST_GRP_ID
+)
+
+// GenlFamily is a generic netlink family as registered with the kernel.
+type GenlFamily struct {
+	Name        string
+	Id          uint16
+	Version     uint8
+	McastGroups map[string]uint32
+}
+
+// GenlFamily resolves the named generic netlink family in the namespace of
+// this context. Families are cached on the context once resolved.
+func (c *Context) GenlFamily(name string) (*GenlFamily, error) {
+
+	c.genlMu.Lock()
+	defer c.genlMu.Unlock()
+
+	if f, ok := c.genl[name]; ok {
+		return f, nil
+	}
+
+	var family *GenlFamily
+	err := withNsGenetlink(c.Fd(), func(conn *netlink.Conn) error {
+		var err error
+		family, err = resolveGenlFamily(conn, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.genl == nil {
+		c.genl = make(map[string]*GenlFamily)
+	}
+	c.genl[name] = family
+
+	return family, nil
+
+}
+
+// GenlExecute sends a command to the named generic netlink family in the
+// namespace of this context and returns the replies. The generic netlink
+// header is stripped from the replies, leaving their attributes as data.
+func (c *Context) GenlExecute(
+	name string, cmd uint8, flags netlink.HeaderFlags, attrs []byte,
+) ([]netlink.Message, error) {
+
+	family, err := c.GenlFamily(name)
+	if err != nil {
+		return nil, err
+	}
+
+	m := genlMessage(family.Id, cmd, family.Version, flags, attrs)
+
+	var result []netlink.Message
+	err = withNsGenetlink(c.Fd(), func(conn *netlink.Conn) error {
+
+		resp, err := conn.Execute(m)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resp {
+			if len(r.Data) < genlHdrLen {
+				continue
+			}
+			r.Data = r.Data[genlHdrLen:]
+			result = append(result, r)
+		}
+
+		return nil
+
+	})
+
+	return result, err
+
+}
+
+// McastGroup returns the id of the named multicast group of the family.
+func (f *GenlFamily) McastGroup(name string) (uint32, error) {
+
+	id, ok := f.McastGroups[name]
+	if !ok {
+		return 0, fmt.Errorf("undefined multicast group %s of %s", name, f.Name)
+	}
+	return id, nil
+
+}
+
+func withNsGenetlink(ns int, f func(*netlink.Conn) error) error {
+
+	return withNsNetlinkProto(ns, unix.NETLINK_GENERIC, f)
+
+}
+
+// genlMessage builds a generic netlink message for the provided family.
+func genlMessage(
+	family uint16, cmd, version uint8, flags netlink.HeaderFlags, attrs []byte,
+) netlink.Message {
+
+	hdr := []byte{cmd, version, 0, 0}
+
+	return netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(family),
+			Flags: flags,
+		},
+		Data: append(hdr, attrs...),
+	}
+
+}
+
+// resolveGenlFamily asks the generic netlink controller about the named
+// family.
+func resolveGenlFamily(conn *netlink.Conn, name string) (*GenlFamily, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.String(CTRL_ATTR_FAMILY_NAME, name)
+	attrs, err := ae.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	m := genlMessage(GENL_ID_CTRL, CTRL_CMD_GETFAMILY, 1, netlink.Request, attrs)
+
+	resp, err := conn.Execute(m)
+	if err != nil {
+		return nil, fmt.Errorf("generic netlink family %s: %v", name, err)
+	}
+
+	for _, r := range resp {
+
+		if len(r.Data) < genlHdrLen {
+			continue
+		}
+
+		family := &GenlFamily{
+			McastGroups: make(map[string]uint32),
+		}
+
+		ad, err := netlink.NewAttributeDecoder(r.Data[genlHdrLen:])
+		if err != nil {
+			return nil, err
+		}
+		for ad.Next() {
+			switch ad.Type() & nlaTypeMask {
+
+			case CTRL_ATTR_FAMILY_ID:
+				family.Id = ad.Uint16()
+
+			case CTRL_ATTR_FAMILY_NAME:
+				family.Name = ad.String()
+
+			case CTRL_ATTR_VERSION:
+				family.Version = uint8(ad.Uint32())
+
+			case CTRL_ATTR_MCAST_GROUPS:
+				nad, err := netlink.NewAttributeDecoder(ad.Bytes())
+				if err != nil {
+					return nil, err
+				}
+				for nad.Next() {
+					gad, err := netlink.NewAttributeDecoder(nad.Bytes())
+					if err != nil {
+						return nil, err
+					}
+					var gname string
+					var gid uint32
+					for gad.Next() {
+						switch gad.Type() & nlaTypeMask {
+						case CTRL_ATTR_MCAST_GRP_NAME:
+							gname = gad.String()
+						case CTRL_ATTR_MCAST_GRP_ID:
+							gid = gad.Uint32()
+						}
+					}
+					family.McastGroups[gname] = gid
+				}
+
+			}
+		}
+		if err := ad.Err(); err != nil {
+			return nil, err
+		}
+
+		if family.Id != 0 {
+			return family, nil
+		}
+
+	}
+
+	return nil, fmt.Errorf("generic netlink family %s not found", name)
+
+}
@@ -0,0 +1,40 @@
+package rtnl
+
+import (
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Ifb encapsulates intermediate functional block links, which ingress
+// traffic can be redirected to for shaping
+type Ifb struct {
+}
+
+func (t *Ifb) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte("ifb"))
+
+		return ae1.Encode()
+
+	})
+
+	return ae.Encode()
+
+}
+
+// Unmarshal is a no-op, ifb links carry no kind specific attributes.
+func (t *Ifb) Unmarshal(ctx *Context, buf []byte) error {
+
+	return nil
+
+}
+
+func (t *Ifb) Resolve(ctx *Context) error {
+
+	return nil
+
+}
@@ -33,6 +33,17 @@ const (
 	VrfType
 	MacvlanType
 	WireguardType
+	GeneveType
+	DummyType
+	IfbType
+	NlmonType
+	XfrmType
+	VtiType
+	Vti6Type
+	MacsecType
+	VcanType
+	VxcanType
+	CanType
 )
 
 // interface link address attribute types
@@ -40,6 +51,9 @@ const (
 	IFLA_INFO_UNSPEC uint16 = iota
 	IFLA_INFO_KIND
 	IFLA_INFO_DATA
+	IFLA_INFO_XSTATS
+	IFLA_INFO_SLAVE_KIND
+	IFLA_INFO_SLAVE_DATA
 )
 
 // Data Structures ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -68,9 +82,18 @@ type LinkInfo struct {
 	// network namespace file descriptor
 	Ns uint32
 
+	// network namespace of a process, used when Ns is not set
+	NsPid uint32
+
 	// maximum transmission unit
 	Mtu uint32
 
+	// transmit queue length
+	TxQLen uint32
+
+	// free form description of the link
+	Alias string
+
 	// the network namespace the link is in
 	LinkNS uint32
 
@@ -82,6 +105,15 @@ type LinkInfo struct {
 	Untagged []uint16
 	Tagged   []uint16
 
+	// vlan to tunnel id mappings of a bridge port in collect metadata mode
+	VlanTunnels []VlanTunnel
+
+	// bridge port properties, present when the master is a bridge
+	BridgePort *BridgePort
+
+	// vrf port properties, present when the master is a vrf
+	VrfPort *VrfPort
+
 	// loopback properties
 	Loopback *Loopback
 
@@ -108,6 +140,49 @@ type LinkInfo struct {
 
 	// wireguard properties
 	Wireguard *Wireguard
+
+	// geneve properties
+	Geneve *Geneve
+
+	// dummy properties
+	Dummy *Dummy
+
+	// ifb properties
+	Ifb *Ifb
+
+	// nlmon properties
+	Nlmon *Nlmon
+
+	// xfrm properties
+	Xfrm *Xfrm
+
+	// vti and vti6 properties
+	Vti *Vti
+
+	// macsec properties
+	Macsec *Macsec
+
+	// vcan properties
+	Vcan *Vcan
+
+	// vxcan properties
+	Vxcan *Vxcan
+
+	// can controller properties
+	Can *Can
+
+	// attached xdp programs, read only
+	Xdp *Xdp
+
+	// SR-IOV virtual functions, read only
+	NumVf uint32
+	Vfs   []VfInfo
+
+	// per address family device configuration, read only, see SetInet4Conf,
+	// SetInet6AddrGenMode and SetInet6Token for changing it
+	Inet4Conf map[Ipv4Devconf]uint32
+	Inet6Conf map[Ipv6Devconf]int32
+	Inet6     *Inet6Info
 }
 
 // Methods ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -140,21 +215,10 @@ func (l Link) Marshal(ctx *Context) ([]byte, error) {
 		ae := netlink.NewAttributeEncoder()
 
 		if l.Info != nil {
-			if l.Info.Name != "" {
-				ae.String(unix.IFLA_IFNAME, l.Info.Name)
-			}
+			l.Info.encodeCommon(ae)
 			if l.Info.Master != 0 {
 				ae.Uint32(unix.IFLA_MASTER, l.Info.Master)
 			}
-			if l.Info.Ns != 0 {
-				ae.Uint32(unix.IFLA_NET_NS_FD, l.Info.Ns)
-			}
-			if l.Info.Address != nil && !isZeroMac(l.Info.Address) {
-				ae.Bytes(unix.IFLA_ADDRESS, l.Info.Address)
-			}
-			if l.Info.Mtu != 0 {
-				ae.Uint32(unix.IFLA_MTU, l.Info.Mtu)
-			}
 			if l.Msg.Family == unix.AF_BRIDGE {
 				ae.Uint32(unix.IFLA_EXT_MASK, 2)
 			}
@@ -181,6 +245,33 @@ func (l Link) Marshal(ctx *Context) ([]byte, error) {
 
 }
 
+// encodeCommon encodes the properties shared by all kinds of links, these are
+// also the properties a veth peer may be created with.
+func (i *LinkInfo) encodeCommon(ae *netlink.AttributeEncoder) {
+
+	if i.Name != "" {
+		ae.String(unix.IFLA_IFNAME, i.Name)
+	}
+	if i.Ns != 0 {
+		ae.Uint32(unix.IFLA_NET_NS_FD, i.Ns)
+	} else if i.NsPid != 0 {
+		ae.Uint32(unix.IFLA_NET_NS_PID, i.NsPid)
+	}
+	if i.Address != nil && !isZeroMac(i.Address) {
+		ae.Bytes(unix.IFLA_ADDRESS, i.Address)
+	}
+	if i.Mtu != 0 {
+		ae.Uint32(unix.IFLA_MTU, i.Mtu)
+	}
+	if i.TxQLen != 0 {
+		ae.Uint32(unix.IFLA_TXQLEN, i.TxQLen)
+	}
+	if i.Alias != "" {
+		ae.String(unix.IFLA_IFALIAS, i.Alias)
+	}
+
+}
+
 // Unmarshal reads a link and its attributes from a binary rtnetlink message.
 func (l *Link) Unmarshal(ctx *Context, bs []byte) error {
 
@@ -212,9 +303,11 @@ func (l *Link) Unmarshal(ctx *Context, bs []byte) error {
 	}
 
 	var lattr Attributes
+	var slaveKind string
 	var link uint32
+	var linkRemote bool
 	for ad.Next() {
-		switch ad.Type() {
+		switch ad.Type() & nlaTypeMask {
 
 		case unix.IFLA_IFNAME:
 			l.Info.Name = ad.String()
@@ -234,7 +327,7 @@ func (l *Link) Unmarshal(ctx *Context, bs []byte) error {
 				continue
 			}
 			for nad.Next() {
-				switch nad.Type() {
+				switch nad.Type() & nlaTypeMask {
 
 				// keep track of the current attribute kind
 				case IFLA_INFO_KIND:
@@ -248,34 +341,64 @@ func (l *Link) Unmarshal(ctx *Context, bs []byte) error {
 						lattr.Unmarshal(ctx, nad.Bytes())
 					}
 
+				case IFLA_INFO_SLAVE_KIND:
+					slaveKind = nad.String()
+
+				case IFLA_INFO_SLAVE_DATA:
+					switch slaveKind {
+					case "bridge":
+						l.Info.BridgePort = &BridgePort{}
+						l.Info.BridgePort.Unmarshal(ctx, nad.Bytes())
+					case "vrf":
+						l.Info.VrfPort = &VrfPort{}
+						l.Info.VrfPort.Unmarshal(ctx, nad.Bytes())
+					}
+
 				}
 			}
 
-		case unix.IFLA_AF_SPEC:
+		case unix.IFLA_NUM_VF:
+			l.Info.NumVf = ad.Uint32()
 
-			nad, err := netlink.NewAttributeDecoder(ad.Bytes())
+		case unix.IFLA_VFINFO_LIST:
+			vfs, err := unmarshalVfInfoList(ad.Bytes())
 			if err != nil {
-				log.WithError(err).Warning("failed to create bridge spec decoder")
+				log.WithError(err).Warning("failed to decode vf info")
 				continue
 			}
-			for nad.Next() {
-				switch nad.Type() {
+			l.Info.Vfs = vfs
+
+		case unix.IFLA_XDP:
+			xdp := &Xdp{}
+			err := xdp.Unmarshal(ctx, ad.Bytes())
+			if err != nil {
+				log.WithError(err).Warning("failed to decode xdp state")
+				continue
+			}
+			if xdp.Attached != XDP_ATTACHED_NONE {
+				l.Info.Xdp = xdp
+			}
 
-				case IFLA_BRIDGE_VLAN_INFO:
-					bs := nad.Bytes()
-					flags := nlenc.Uint16(bs[:2])
-					vid := nlenc.Uint16(bs[2:4])
+		case unix.IFLA_PROTINFO:
+			if l.Msg.Family == unix.AF_BRIDGE {
+				l.Info.BridgePort = &BridgePort{}
+				l.Info.BridgePort.Unmarshal(ctx, ad.Bytes())
+			}
 
-					if (flags & BRIDGE_VLAN_INFO_PVID) != 0 {
-						l.Info.Pvid = vid
-					}
+		case unix.IFLA_AF_SPEC:
 
-					if (flags & BRIDGE_VLAN_INFO_UNTAGGED) != 0 {
-						l.Info.Untagged = append(l.Info.Untagged, vid)
-					} else {
-						l.Info.Tagged = append(l.Info.Tagged, vid)
-					}
+			// outside the bridge family the spec is keyed by address family
+			if l.Msg.Family != unix.AF_BRIDGE {
+				err := l.Info.unmarshalInetAfSpec(ad.Bytes())
+				if err != nil {
+					log.WithError(err).Warning("failed to decode inet spec")
 				}
+				continue
+			}
+
+			err := l.Info.unmarshalBridgeAfSpec(ad.Bytes())
+			if err != nil {
+				log.WithError(err).Warning("failed to decode bridge spec")
 			}
 
 		case unix.IFLA_LINK:
@@ -284,19 +407,40 @@ func (l *Link) Unmarshal(ctx *Context, bs []byte) error {
 		case unix.IFLA_MTU:
 			l.Info.Mtu = ad.Uint32()
 
+		case unix.IFLA_TXQLEN:
+			l.Info.TxQLen = ad.Uint32()
+
+		case unix.IFLA_IFALIAS:
+			l.Info.Alias = ad.String()
+
 		case unix.IFLA_NET_NS_FD:
 			l.Info.Ns = ad.Uint32()
 
 		case unix.IFLA_LINK_NETNSID:
 			l.Info.LinkNS = ad.Uint32()
+			linkRemote = true
 
 		}
 	}
 
-	// grap veth specific things
+	// grap veth specific things, vxcan peers are reported in the same way
 	veth, ok := lattr.(*Veth)
+	if vxcan, isVxcan := lattr.(*Vxcan); isVxcan {
+		veth, ok = &vxcan.Veth, true
+	}
 	if ok {
 		veth.PeerIfx = link
+		if linkRemote {
+			veth.PeerNS = l.Info.LinkNS
+			veth.PeerRemote = true
+		}
+	}
+
+	// the kernel reports tap devices as tun devices
+	tun, ok := lattr.(*Tun)
+	if ok && tun.typ == unix.IFF_TAP {
+		l.Info.Tap = &Tap{TunTap: tun.TunTap}
+		l.Info.Tun = nil
 	}
 
 	// grap macvlan specific things
@@ -305,6 +449,12 @@ func (l *Link) Unmarshal(ctx *Context, bs []byte) error {
 		macvlan.Link = link
 	}
 
+	// grap macsec specific things
+	macsec, ok := lattr.(*Macsec)
+	if ok {
+		macsec.Link = link
+	}
+
 	// should not happen
 	if l.Info.Name == "" {
 
@@ -402,11 +552,69 @@ func (l *Link) SetMaster(ctx *Context, index int) error {
 
 }
 
+// UnsetMaster releases the link from its master.
+func (l *Link) UnsetMaster(ctx *Context) error {
+
+	err := l.Read(ctx)
+	if err != nil {
+		return err
+	}
+
+	msg := IfInfomsgBytes(l.Msg)
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(unix.IFLA_MASTER, 0)
+
+	attrs, err := ae.Encode()
+	if err != nil {
+		return err
+	}
+
+	data := append(msg, attrs...)
+
+	flags := netlink.Request |
+		netlink.Acknowledge |
+		netlink.Excl
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.RTM_SETLINK),
+			Flags: flags,
+		},
+		Data: data,
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
+
+}
+
 // ReadLinks reads a set of links according to the provided specification. For
 // example, if you specify the address family, only links from that family will
 // be returned. Some basic attribute filtering is also implemented.
 func ReadLinks(ctx *Context, spec *Link) ([]*Link, error) {
 
+	result, err := readLinks(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range result {
+		for _, a := range l.Attributes() {
+			err := a.Resolve(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+
+}
+
+// readLinks reads a set of links without resolving their attributes, which is
+// what resolving a veth peer uses to look the peer up.
+func readLinks(ctx *Context, spec *Link) ([]*Link, error) {
+
 	var result []*Link
 
 	m := netlink.Message{
@@ -435,6 +643,17 @@ func ReadLinks(ctx *Context, spec *Link) ([]*Link, error) {
 		log.WithError(err).Error("failed to marshal spec link")
 		return nil, err
 	}
+
+	// virtual functions are only reported when asked for
+	if spec.Msg.Family != unix.AF_BRIDGE {
+		ae := netlink.NewAttributeEncoder()
+		ae.Uint32(unix.IFLA_EXT_MASK, RTEXT_FILTER_VF)
+		mask, err := ae.Encode()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, mask...)
+	}
 	m.Data = data
 
 	err = withNsNetlink(ctx.Fd(), func(conn *netlink.Conn) error {
@@ -476,7 +695,7 @@ func (l *Link) Read(ctx *Context) error {
 		spec.Info.Name = l.Info.Name
 	}
 
-	links, err := ReadLinks(ctx, spec)
+	links, err := readLinks(ctx, spec)
 	if err != nil {
 		return err
 	}
@@ -492,6 +711,10 @@ func (l *Link) Read(ctx *Context) error {
 		l.Info.Untagged = links[0].Info.Untagged
 		l.Info.Tagged = links[0].Info.Tagged
 		l.Info.Pvid = links[0].Info.Pvid
+		l.Info.VlanTunnels = links[0].Info.VlanTunnels
+		if links[0].Info.BridgePort != nil {
+			l.Info.BridgePort = links[0].Info.BridgePort
+		}
 	} else {
 		*l = *links[0]
 	}
@@ -566,6 +789,50 @@ func (l *Link) ApplyType(typ string) Attributes {
 		l.Info.Wireguard = &Wireguard{}
 		return l.Info.Wireguard
 
+	case "geneve":
+		l.Info.Geneve = &Geneve{}
+		return l.Info.Geneve
+
+	case "dummy":
+		l.Info.Dummy = &Dummy{}
+		return l.Info.Dummy
+
+	case "ifb":
+		l.Info.Ifb = &Ifb{}
+		return l.Info.Ifb
+
+	case "nlmon":
+		l.Info.Nlmon = &Nlmon{}
+		return l.Info.Nlmon
+
+	case "xfrm":
+		l.Info.Xfrm = &Xfrm{}
+		return l.Info.Xfrm
+
+	case "vti":
+		l.Info.Vti = &Vti{}
+		return l.Info.Vti
+
+	case "vti6":
+		l.Info.Vti = &Vti{Ipv6: true}
+		return l.Info.Vti
+
+	case "macsec":
+		l.Info.Macsec = &Macsec{}
+		return l.Info.Macsec
+
+	case "vcan":
+		l.Info.Vcan = &Vcan{}
+		return l.Info.Vcan
+
+	case "vxcan":
+		l.Info.Vxcan = &Vxcan{}
+		return l.Info.Vxcan
+
+	case "can":
+		l.Info.Can = &Can{}
+		return l.Info.Can
+
 	}
 
 	log.Tracef("unknown type %s", typ)
@@ -603,6 +870,39 @@ func (li *LinkInfo) Type() LinkType {
 	if li.Wireguard != nil {
 		return WireguardType
 	}
+	if li.Geneve != nil {
+		return GeneveType
+	}
+	if li.Dummy != nil {
+		return DummyType
+	}
+	if li.Ifb != nil {
+		return IfbType
+	}
+	if li.Nlmon != nil {
+		return NlmonType
+	}
+	if li.Xfrm != nil {
+		return XfrmType
+	}
+	if li.Vti != nil {
+		if li.Vti.Kind() == "vti6" {
+			return Vti6Type
+		}
+		return VtiType
+	}
+	if li.Macsec != nil {
+		return MacsecType
+	}
+	if li.Vcan != nil {
+		return VcanType
+	}
+	if li.Vxcan != nil {
+		return VxcanType
+	}
+	if li.Can != nil {
+		return CanType
+	}
 
 	//TODO Is this a reasonable default? Given the logic of how types are
 	//ascertained i think its at least decent.
@@ -639,6 +939,46 @@ func (l *Link) Attributes() []Attributes {
 		result = append(result, l.Info.Wireguard)
 	}
 
+	if l.Info != nil && l.Info.Geneve != nil {
+		result = append(result, l.Info.Geneve)
+	}
+
+	if l.Info != nil && l.Info.Dummy != nil {
+		result = append(result, l.Info.Dummy)
+	}
+
+	if l.Info != nil && l.Info.Ifb != nil {
+		result = append(result, l.Info.Ifb)
+	}
+
+	if l.Info != nil && l.Info.Nlmon != nil {
+		result = append(result, l.Info.Nlmon)
+	}
+
+	if l.Info != nil && l.Info.Xfrm != nil {
+		result = append(result, l.Info.Xfrm)
+	}
+
+	if l.Info != nil && l.Info.Vti != nil {
+		result = append(result, l.Info.Vti)
+	}
+
+	if l.Info != nil && l.Info.Macsec != nil {
+		result = append(result, l.Info.Macsec)
+	}
+
+	if l.Info != nil && l.Info.Vcan != nil {
+		result = append(result, l.Info.Vcan)
+	}
+
+	if l.Info != nil && l.Info.Vxcan != nil {
+		result = append(result, l.Info.Vxcan)
+	}
+
+	if l.Info != nil && l.Info.Can != nil {
+		result = append(result, l.Info.Can)
+	}
+
 	return result
 
 }
@@ -648,18 +988,116 @@ func (l *Link) Attributes() []Attributes {
 // Add the link to the kernel.
 func (l *Link) Add(ctx *Context) error {
 
-	err := l.Modify(ctx, unix.RTM_NEWLINK)
+	// the kernel does not apply aliases at creation time, so they are set once
+	// the links exist
+	var alias string
+	var peerSpec *Veth
+	if l.Info != nil {
+		alias = l.Info.Alias
+		if v := l.Info.peered(); v != nil {
+			spec := *v
+			peerSpec = &spec
+		}
+	}
+
+	// tun and tap devices cannot be created through rtnetlink
+	var err error
+	tuntap, typ := l.tunTap()
+	if tuntap != nil {
+		err = l.addTunTap(ctx, tuntap, typ)
+	} else {
+		err = l.Modify(ctx, unix.RTM_NEWLINK)
+	}
 	if err != nil {
 		return err
 	}
 
 	// read kernel info about the link
+	rctx := ctx
 	if ctx.Target != nil {
-		return l.Read(ctx.Target)
-	} else {
-		return l.Read(ctx)
+		rctx = ctx.Target
+	}
+	err = l.Read(rctx)
+	if err != nil {
+		return err
 	}
 
+	if tuntap != nil {
+		if t, _ := l.tunTap(); t != nil {
+			t.KeepQueues = tuntap.KeepQueues
+			t.Queues = tuntap.Queues
+		}
+	}
+
+	// the read replaces the link info, keep the peer as the caller specified
+	// it alongside what the kernel reported
+	var peer *LinkInfo
+	if v := l.Info.peered(); v != nil && peerSpec != nil {
+		peer = peerSpec.PeerInfo
+		v.PeerInfo = peer
+		if v.Peer == "" {
+			v.Peer = peerSpec.Peer
+		}
+		if v.PeerNamespace == "" {
+			v.PeerNamespace = peerSpec.PeerNamespace
+		}
+	}
+
+	if alias != "" {
+		err = setLinkAlias(rctx.Fd(), l.Msg.Index, alias)
+		if err != nil {
+			return err
+		}
+	}
+
+	if peer != nil && peer.Alias != "" {
+		err = withPeerNs(ctx, peer, func(ns int) error {
+			return setLinkAlias(ns, int32(l.Info.peered().PeerIfx), peer.Alias)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// peered returns the peer properties of veth and vxcan links, or nil for
+// other links.
+func (li *LinkInfo) peered() *Veth {
+
+	if li.Veth != nil {
+		return li.Veth
+	}
+	if li.Vxcan != nil {
+		return &li.Vxcan.Veth
+	}
+	return nil
+
+}
+
+// setLinkAlias sets the alias of the link with the provided index in the
+// namespace referred to by ns.
+func setLinkAlias(ns int, index int32, alias string) error {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.String(unix.IFLA_IFALIAS, alias)
+	attrs, err := ae.Encode()
+	if err != nil {
+		return err
+	}
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.RTM_SETLINK),
+			Flags: netlink.Request | netlink.Acknowledge,
+		},
+		Data: append(IfInfomsgBytes(unix.IfInfomsg{Index: index}), attrs...),
+	}
+
+	return netlinkNsUpdate(ns, []netlink.Message{m})
+
 }
 
 // Present ensures the link is present.
@@ -871,21 +1309,15 @@ func (l *Link) SetVlan(ctx *Context, vid uint16, unset, untagged, pvid, self boo
 	ae.Do(unix.IFLA_AF_SPEC, func() ([]byte, error) {
 
 		ae1 := netlink.NewAttributeEncoder()
-		ae1.Do(IFLA_BRIDGE_VLAN_INFO, func() ([]byte, error) {
-
-			var fl uint16 = 0
-			if untagged {
-				fl |= BRIDGE_VLAN_INFO_UNTAGGED
-			}
-			if pvid {
-				fl |= BRIDGE_VLAN_INFO_PVID
-			}
-
-			flags := nlenc.Uint16Bytes(fl)
-			evid := nlenc.Uint16Bytes(vid)
-			return append(flags, evid...), nil
 
-		})
+		var fl uint16 = 0
+		if untagged {
+			fl |= BRIDGE_VLAN_INFO_UNTAGGED
+		}
+		if pvid {
+			fl |= BRIDGE_VLAN_INFO_PVID
+		}
+		ae1.Bytes(IFLA_BRIDGE_VLAN_INFO, vlanInfoBytes(fl, vid))
 
 		if self {
 			ae1.Uint16(IFLA_BRIDGE_FLAGS, BRIDGE_FLAGS_SELF)
@@ -922,6 +1354,212 @@ func (l *Link) SetVlan(ctx *Context, vid uint16, unset, untagged, pvid, self boo
 
 }
 
+// SetVlanRange sets or unsets the vlans begin through end on the link in a
+// single request.
+func (l *Link) SetVlanRange(
+	ctx *Context, begin, end uint16, unset, untagged, self bool) error {
+
+	if begin == 0 || end < begin {
+		return fmt.Errorf("invalid vlan range %d-%d", begin, end)
+	}
+
+	var fl uint16 = 0
+	if untagged {
+		fl |= BRIDGE_VLAN_INFO_UNTAGGED
+	}
+
+	return l.bridgeAfSpec(ctx, unset, func(ae *netlink.AttributeEncoder) {
+
+		if begin == end {
+			ae.Bytes(IFLA_BRIDGE_VLAN_INFO, vlanInfoBytes(fl, begin))
+		} else {
+			ae.Bytes(IFLA_BRIDGE_VLAN_INFO,
+				vlanInfoBytes(fl|BRIDGE_VLAN_INFO_RANGE_BEGIN, begin))
+			ae.Bytes(IFLA_BRIDGE_VLAN_INFO,
+				vlanInfoBytes(fl|BRIDGE_VLAN_INFO_RANGE_END, end))
+		}
+
+		if self {
+			ae.Uint16(IFLA_BRIDGE_FLAGS, BRIDGE_FLAGS_SELF)
+		}
+
+	})
+
+}
+
+// SetVlanTunnel maps or unmaps a vlan to a tunnel id on a bridge port in
+// collect metadata mode, such as an external vxlan.
+func (l *Link) SetVlanTunnel(
+	ctx *Context, vid uint16, tunnelId uint32, unset bool) error {
+
+	return l.SetVlanTunnelRange(ctx, vid, vid, tunnelId, unset)
+
+}
+
+// SetVlanTunnelRange maps or unmaps the vlans begin through end to the tunnel
+// ids starting at tunnelId in a single request.
+func (l *Link) SetVlanTunnelRange(
+	ctx *Context, begin, end uint16, tunnelId uint32, unset bool) error {
+
+	if begin == 0 || end < begin {
+		return fmt.Errorf("invalid vlan range %d-%d", begin, end)
+	}
+
+	return l.bridgeAfSpec(ctx, unset, func(ae *netlink.AttributeEncoder) {
+
+		if begin == end {
+			ae.Do(IFLA_BRIDGE_VLAN_TUNNEL_INFO,
+				vlanTunnelInfoBytes(tunnelId, begin, 0))
+		} else {
+			ae.Do(IFLA_BRIDGE_VLAN_TUNNEL_INFO, vlanTunnelInfoBytes(
+				tunnelId, begin, BRIDGE_VLAN_INFO_RANGE_BEGIN))
+			ae.Do(IFLA_BRIDGE_VLAN_TUNNEL_INFO, vlanTunnelInfoBytes(
+				tunnelId+uint32(end-begin), end, BRIDGE_VLAN_INFO_RANGE_END))
+		}
+
+	})
+
+}
+
+// unmarshalBridgeAfSpec reads the vlans and vlan tunnels of a bridge port from
+// a bridge family IFLA_AF_SPEC attribute. Ranges arrive as a begin entry
+// followed by an end entry, an end without a begin is skipped.
+func (li *LinkInfo) unmarshalBridgeAfSpec(buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return err
+	}
+
+	var vlanBegin *uint16
+	var tunnelBegin *VlanTunnel
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+
+		case IFLA_BRIDGE_VLAN_INFO:
+			info := ad.Bytes()
+			if len(info) < 4 {
+				continue
+			}
+			flags := nlenc.Uint16(info[:2])
+			vid := nlenc.Uint16(info[2:4])
+
+			if (flags & BRIDGE_VLAN_INFO_RANGE_BEGIN) != 0 {
+				vlanBegin = &vid
+				continue
+			}
+			begin := vid
+			if (flags & BRIDGE_VLAN_INFO_RANGE_END) != 0 {
+				if vlanBegin == nil {
+					log.Warningf("vlan range end %d without a begin", vid)
+					continue
+				}
+				begin = *vlanBegin
+				vlanBegin = nil
+			}
+
+			for v := int(begin); v <= int(vid); v++ {
+
+				if (flags & BRIDGE_VLAN_INFO_PVID) != 0 {
+					li.Pvid = uint16(v)
+				}
+
+				if (flags & BRIDGE_VLAN_INFO_UNTAGGED) != 0 {
+					li.Untagged = append(li.Untagged, uint16(v))
+				} else {
+					li.Tagged = append(li.Tagged, uint16(v))
+				}
+
+			}
+
+		case IFLA_BRIDGE_VLAN_TUNNEL_INFO:
+			id, vid, flags, err := decodeVlanTunnelInfo(ad.Bytes())
+			if err != nil {
+				log.WithError(err).Warning("failed to decode vlan tunnel info")
+				continue
+			}
+
+			if (flags & BRIDGE_VLAN_INFO_RANGE_BEGIN) != 0 {
+				tunnelBegin = &VlanTunnel{Vid: vid, TunnelId: id}
+				continue
+			}
+			begin := VlanTunnel{Vid: vid, TunnelId: id}
+			if (flags & BRIDGE_VLAN_INFO_RANGE_END) != 0 {
+				if tunnelBegin == nil {
+					log.Warningf("vlan tunnel range end %d without a begin", vid)
+					continue
+				}
+				begin = *tunnelBegin
+				tunnelBegin = nil
+			}
+
+			for v := int(begin.Vid); v <= int(vid); v++ {
+				li.VlanTunnels = append(li.VlanTunnels, VlanTunnel{
+					Vid:      uint16(v),
+					TunnelId: begin.TunnelId + uint32(v-int(begin.Vid)),
+				})
+			}
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+// bridgeAfSpec sends the bridge IFLA_AF_SPEC attributes produced by fn for
+// this link, using RTM_DELLINK when unset is true and RTM_SETLINK otherwise.
+func (l *Link) bridgeAfSpec(
+	ctx *Context, unset bool, fn func(*netlink.AttributeEncoder)) error {
+
+	if l.Msg.Index == 0 {
+		err := l.Read(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	msg := l.Msg
+	msg.Family = unix.AF_BRIDGE
+	msg.Change = 0
+	data := IfInfomsgBytes(msg)
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_AF_SPEC, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		fn(ae1)
+		return ae1.Encode()
+
+	})
+
+	attrs, err := ae.Encode()
+	if err != nil {
+		return err
+	}
+	data = append(data, attrs...)
+
+	flags := netlink.Request |
+		netlink.Acknowledge |
+		netlink.Excl
+
+	op := unix.RTM_SETLINK
+	if unset {
+		op = unix.RTM_DELLINK
+	}
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(op),
+			Flags: flags,
+		},
+		Data: data,
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
+
+}
+
 func (l *Link) AddAddr(ctx *Context, addr *Address) error {
 
 	addr.Msg.Index = uint32(l.Msg.Index)
@@ -929,6 +1567,13 @@ func (l *Link) AddAddr(ctx *Context, addr *Address) error {
 
 }
 
+func (l *Link) DelAddr(ctx *Context, addr *Address) error {
+
+	addr.Msg.Index = uint32(l.Msg.Index)
+	return DelAddr(ctx, addr)
+
+}
+
 // Satisfies returns true if this link satisfies the provided spec.
 func (l *Link) Satisfies(spec *Link) bool {
 
@@ -975,6 +1620,28 @@ func (lt LinkType) String() string {
 		return "macvlan"
 	case WireguardType:
 		return "wireguard"
+	case GeneveType:
+		return "geneve"
+	case DummyType:
+		return "dummy"
+	case IfbType:
+		return "ifb"
+	case NlmonType:
+		return "nlmon"
+	case XfrmType:
+		return "xfrm"
+	case VtiType:
+		return "vti"
+	case Vti6Type:
+		return "vti6"
+	case MacsecType:
+		return "macsec"
+	case VcanType:
+		return "vcan"
+	case VxcanType:
+		return "vxcan"
+	case CanType:
+		return "can"
 	default:
 		return "unspec"
 	}
@@ -1004,6 +1671,28 @@ func ParseLinkType(str string) LinkType {
 		return MacvlanType
 	case "wireguard":
 		return WireguardType
+	case "geneve":
+		return GeneveType
+	case "dummy":
+		return DummyType
+	case "ifb":
+		return IfbType
+	case "nlmon":
+		return NlmonType
+	case "xfrm":
+		return XfrmType
+	case "vti":
+		return VtiType
+	case "vti6":
+		return Vti6Type
+	case "macsec":
+		return MacsecType
+	case "vcan":
+		return VcanType
+	case "vxcan":
+		return VxcanType
+	case "can":
+		return CanType
 	default:
 		return UnspecLinkType
 	}
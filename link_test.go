@@ -1,12 +1,18 @@
 package rtnl
 
 import (
+	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/exec"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
 )
 
 type IProute2Link struct {
@@ -266,6 +272,146 @@ func Test_VethAddress(t *testing.T) {
 
 }
 
+func Test_VethPeer(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	va := &Link{
+		Info: &LinkInfo{
+			Name: "vethA",
+			Veth: &Veth{
+				Peer: "vethB",
+			},
+		},
+	}
+	err = va.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer va.Del(ctx)
+
+	vb, err := GetLink(ctx, "vethB")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a peer in the same namespace is resolved on every read
+	ra, err := GetLink(ctx, "vethA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ra.Info.Veth == nil || ra.Info.Veth.Peer != "vethB" ||
+		ra.Info.Veth.PeerIfx != uint32(vb.Msg.Index) {
+		t.Fatalf("unexpected peer read back %+v", ra.Info.Veth)
+	}
+
+	links, err := ReadLinks(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, l := range links {
+		if l.Info.Name == "vethB" {
+			found = true
+			if l.Info.Veth == nil || l.Info.Veth.Peer != "vethA" {
+				t.Fatalf("unexpected peer listed %+v", l.Info.Veth)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("vethB not listed")
+	}
+
+}
+
+func Test_VethPeerInfo(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	out, err := exec.Command("ip", "netns", "add", "muffin").CombinedOutput()
+	if err != nil {
+		t.Fatal(string(out))
+	}
+	defer exec.Command("ip", "netns", "del", "muffin").Run()
+
+	tctx, err := OpenContext("muffin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tctx.Close()
+
+	mac, _ := net.ParseMAC("02:00:00:00:00:47")
+
+	va := &Link{
+		Info: &LinkInfo{
+			Name: "vethA",
+			Veth: &Veth{
+				Peer: "vethB",
+				PeerInfo: &LinkInfo{
+					Ns:      uint32(tctx.Fd()),
+					Address: mac,
+					Mtu:     1400,
+					Alias:   "muffin-side",
+				},
+			},
+		},
+	}
+	err = va.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer va.Del(ctx)
+
+	// the peer spec survives the read that follows creation
+	if va.Info.Veth == nil || va.Info.Veth.Peer != "vethB" {
+		t.Fatalf("peer name lost on add %+v", va.Info.Veth)
+	}
+	pi := va.Info.Veth.PeerInfo
+	if pi == nil || pi.Ns != uint32(tctx.Fd()) || pi.Mtu != 1400 ||
+		pi.Alias != "muffin-side" {
+		t.Fatalf("peer info lost on add %+v", pi)
+	}
+
+	vb, err := GetLink(tctx, "vethB")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vb.Info.Address.String() != mac.String() {
+		t.Fatalf("peer address mismatch %s != %s", vb.Info.Address, mac)
+	}
+	if vb.Info.Mtu != 1400 {
+		t.Fatalf("peer mtu mismatch %d != 1400", vb.Info.Mtu)
+	}
+	if vb.Info.Alias != "muffin-side" {
+		t.Fatalf("peer alias mismatch %s != muffin-side", vb.Info.Alias)
+	}
+
+	// the peer is resolved across namespaces on request
+	ra, err := GetLink(ctx, "vethA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ra.Info.Veth.ResolvePeerNamespace(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ra.Info.Veth.Peer != "vethB" || ra.Info.Veth.PeerNamespace != "muffin" {
+		t.Fatalf("peer resolution mismatch %s@%s != vethB@muffin",
+			ra.Info.Veth.Peer, ra.Info.Veth.PeerNamespace,
+		)
+	}
+
+}
+
 func Test_Bridge(t *testing.T) {
 
 	ctx, err := OpenDefaultContext()
@@ -338,7 +484,7 @@ func Test_Bridge(t *testing.T) {
 
 }
 
-func Test_Vxlan(t *testing.T) {
+func Test_BridgeOptions(t *testing.T) {
 
 	ctx, err := OpenDefaultContext()
 	if err != nil {
@@ -346,51 +492,188 @@ func Test_Vxlan(t *testing.T) {
 	}
 	defer ctx.Close()
 
-	lo, err := GetLink(ctx, "lo")
+	var (
+		prio          uint16 = 0x1000
+		pvid          uint16 = 0
+		fwdDelay      uint32 = 400
+		ageing        uint32 = 10000
+		router        uint8  = 0
+		snooping             = false
+		lastMemberCnt uint32 = 3
+	)
+
+	br := &Link{
+		Info: &LinkInfo{
+			Name: "bropt47",
+			Bridge: &Bridge{
+				Priority:           &prio,
+				DefaultPvid:        &pvid,
+				ForwardDelay:       &fwdDelay,
+				AgeingTime:         &ageing,
+				McastRouter:        &router,
+				McastSnooping:      &snooping,
+				McastLastMemberCnt: &lastMemberCnt,
+			},
+		},
+	}
+	err = br.Add(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer br.Del(ctx)
 
-	vx := &Link{
+	err = br.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rb, err := GetLink(ctx, "bropt47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := rb.Info.Bridge
+	if b == nil {
+		t.Fatal("bridge info not decoded")
+	}
+
+	if b.Priority == nil || *b.Priority != prio {
+		t.Errorf("priority %v", b.Priority)
+	}
+	if b.DefaultPvid == nil || *b.DefaultPvid != 0 {
+		t.Errorf("default pvid %v", b.DefaultPvid)
+	}
+	if b.ForwardDelay == nil || *b.ForwardDelay != fwdDelay {
+		t.Errorf("forward delay %v", b.ForwardDelay)
+	}
+	if b.AgeingTime == nil || *b.AgeingTime != ageing {
+		t.Errorf("ageing time %v", b.AgeingTime)
+	}
+	if b.McastRouter == nil || *b.McastRouter != 0 {
+		t.Errorf("mcast router %v", b.McastRouter)
+	}
+	if b.McastSnooping == nil || *b.McastSnooping {
+		t.Errorf("mcast snooping %v", b.McastSnooping)
+	}
+	if b.McastLastMemberCnt == nil || *b.McastLastMemberCnt != lastMemberCnt {
+		t.Errorf("mcast last member count %v", b.McastLastMemberCnt)
+	}
+
+	// read only state, without stp the bridge is its own root
+	if b.BridgeId == nil || b.BridgeId.Priority != prio {
+		t.Fatalf("bridge id %v", b.BridgeId)
+	}
+	if b.BridgeId.Address.String() != rb.Info.Address.String() {
+		t.Errorf("bridge id address %s", b.BridgeId.Address)
+	}
+	if b.RootId == nil || b.RootId.String() != b.BridgeId.String() {
+		t.Errorf("root id %v", b.RootId)
+	}
+	if b.RootPort != 0 || b.RootPathCost != 0 {
+		t.Errorf("root port %d cost %d", b.RootPort, b.RootPathCost)
+	}
+	if b.TopologyChange || b.TcnTimer != 0 || b.TopologyChangeTimer != 0 {
+		t.Errorf("unexpected topology change state %+v", b)
+	}
+	// the fdb garbage collector runs while the bridge is up
+	if b.GcTimer == 0 {
+		t.Error("gc timer not running")
+	}
+
+}
+
+func Test_BridgePort(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	br := &Link{
 		Info: &LinkInfo{
-			Name: "vtep47",
-			Vxlan: &Vxlan{
-				Vni:     47,
-				DstPort: 4789,
-				Local:   net.ParseIP("1.2.3.4"),
-				Link:    uint32(lo.Msg.Index),
-			},
+			Name:   "br47",
+			Bridge: &Bridge{},
 		},
 	}
+	err = br.Present(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Del(ctx)
 
-	err = vx.Add(ctx)
+	va := &Link{
+		Info: &LinkInfo{
+			Name:   "vethA",
+			Master: uint32(br.Msg.Index),
+			Veth: &Veth{
+				Peer: "vethB",
+			},
+		},
+	}
+	err = va.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer va.Del(ctx)
+
+	cost := uint32(47)
+	isolated := true
+	err = va.SetBridgePort(ctx, &BridgePort{
+		Cost:     &cost,
+		Isolated: &isolated,
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	xv, err := GetLink(ctx, "vtep47")
+	av, err := GetLink(ctx, "vethA")
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if err == nil {
-		if xv.Info.Vxlan == nil {
-			t.Error("no vxlan data")
-		}
-		if !reflect.DeepEqual(*xv.Info.Vxlan, *vx.Info.Vxlan) {
-			t.Error("vxlans do not match")
-			t.Logf("expected: %#v", *vx.Info.Vxlan)
-			t.Logf("actual: %#v", *xv.Info.Vxlan)
-		}
+	port := av.Info.BridgePort
+	if port == nil {
+		t.Fatal("no bridge port data")
+	}
+	if port.Cost == nil || *port.Cost != cost {
+		t.Error("bridge port cost readback failed")
+	}
+	if port.Isolated == nil || !*port.Isolated {
+		t.Error("bridge port isolated readback failed")
+	}
+
+}
+
+func Test_BridgeAfSpecUnmatchedEnd(t *testing.T) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Bytes(IFLA_BRIDGE_VLAN_INFO, vlanInfoBytes(BRIDGE_VLAN_INFO_RANGE_END, 4000))
+	ae.Bytes(IFLA_BRIDGE_VLAN_INFO, vlanInfoBytes(0, 47))
+	ae.Bytes(IFLA_BRIDGE_VLAN_INFO, vlanInfoBytes(BRIDGE_VLAN_INFO_RANGE_BEGIN, 100))
+	ae.Bytes(IFLA_BRIDGE_VLAN_INFO, vlanInfoBytes(BRIDGE_VLAN_INFO_RANGE_END, 102))
+	ae.Do(IFLA_BRIDGE_VLAN_TUNNEL_INFO,
+		vlanTunnelInfoBytes(5000, 200, BRIDGE_VLAN_INFO_RANGE_END))
+	buf, err := ae.Encode()
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	err = vx.Del(ctx)
+	li := &LinkInfo{}
+	err = li.unmarshalBridgeAfSpec(buf)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	expected := []uint16{47, 100, 101, 102}
+	if !reflect.DeepEqual(li.Tagged, expected) {
+		t.Errorf("expected vlans %v, found %v", expected, li.Tagged)
+	}
+	if len(li.VlanTunnels) != 0 {
+		t.Errorf("unexpected vlan tunnels %v", li.VlanTunnels)
+	}
+
 }
 
-func Test_Wg(t *testing.T) {
+func Test_BridgeVlanTunnelRange(t *testing.T) {
 
 	ctx, err := OpenDefaultContext()
 	if err != nil {
@@ -398,35 +681,1725 @@ func Test_Wg(t *testing.T) {
 	}
 	defer ctx.Close()
 
-	ctx, err = OpenDefaultContext()
+	br := &Link{
+		Info: &LinkInfo{
+			Name:   "brtun47",
+			Bridge: &Bridge{VlanAware: true},
+		},
+	}
+	err = br.Add(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer br.Del(ctx)
 
-	// create wg link
-
-	lnk := &Link{
+	vx := &Link{
 		Info: &LinkInfo{
-			Name:      "wgtest",
-			Wireguard: &Wireguard{},
+			Name:   "vxtun47",
+			Master: uint32(br.Msg.Index),
+			Vxlan: &Vxlan{
+				DstPort:         4789,
+				CollectMetadata: true,
+			},
 		},
 	}
+	err = vx.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vx.Del(ctx)
 
-	err = lnk.Add(ctx)
+	tunnel := true
+	err = vx.SetBridgePort(ctx, &BridgePort{VlanTunnel: &tunnel})
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer lnk.Absent(ctx)
 
-	// fetch wg link
+	err = vx.SetVlanRange(ctx, 100, 110, false, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vx.SetVlanTunnelRange(ctx, 100, 110, 5000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	wl, err := GetLink(ctx, "wgtest")
+	port := &Link{
+		Msg:  unix.IfInfomsg{Family: unix.AF_BRIDGE, Index: vx.Msg.Index},
+		Info: &LinkInfo{},
+	}
+	err = port.Read(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if wl.Info.Wireguard == nil {
-		t.Error("exepcted wireguard link")
+	var tagged []uint16
+	for _, v := range port.Info.Tagged {
+		if v >= 100 && v <= 110 {
+			tagged = append(tagged, v)
+		}
+	}
+	if len(tagged) != 11 {
+		t.Errorf("expected vlans 100-110, found %v", port.Info.Tagged)
+	}
+
+	if len(port.Info.VlanTunnels) != 11 {
+		t.Fatalf("expected 11 vlan tunnels, found %v", port.Info.VlanTunnels)
+	}
+	for i, vt := range port.Info.VlanTunnels {
+		if vt.Vid != uint16(100+i) || vt.TunnelId != uint32(5000+i) {
+			t.Errorf("unexpected vlan tunnel %+v", vt)
+		}
+	}
+
+}
+
+// an RTM_NEWVLAN message as dumped by the kernel with stats for vlans 10-20 on
+// the port with index 5
+var kernelNewVlanMsg = []byte{
+	0x07, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, // br_vlan_msg
+	0x80, 0x00, 0x01, 0x80, // BRIDGE_VLANDB_ENTRY
+	0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x0a, 0x00, // ENTRY_INFO
+	0x06, 0x00, 0x02, 0x00, 0x14, 0x00, 0x00, 0x00, // ENTRY_RANGE
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x00, 0x00, 0x00, // ENTRY_STATE
+	0x05, 0x00, 0x06, 0x00, 0x01, 0x00, 0x00, 0x00, // ENTRY_MCAST_ROUTER
+	0x08, 0x00, 0x07, 0x00, 0x02, 0x00, 0x00, 0x00, // ENTRY_MCAST_N_GROUPS
+	0x08, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, // ENTRY_MCAST_MAX_GROUPS
+	0x05, 0x00, 0x09, 0x00, 0x01, 0x00, 0x00, 0x00, // ENTRY_NEIGH_SUPPRESS
+	0x44, 0x00, 0x05, 0x80, // ENTRY_STATS
+	0x04, 0x00, 0x05, 0x00, // STATS_PAD
+	0x0c, 0x00, 0x01, 0x00, 0xe8, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x04, 0x00, 0x05, 0x00,
+	0x0c, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x04, 0x00, 0x05, 0x00,
+	0x0c, 0x00, 0x03, 0x00, 0xd0, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x04, 0x00, 0x05, 0x00,
+	0x0c, 0x00, 0x04, 0x00, 0x14, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+func Test_BridgeVlanUnmarshal(t *testing.T) {
+
+	var vlans []*BridgeVlan
+	err := unmarshalBrVlanMsg(kernelNewVlanMsg,
+		func(ifindex uint32, typ uint16, buf []byte) error {
+			if typ != BRIDGE_VLANDB_ENTRY {
+				t.Errorf("unexpected attribute type %d", typ)
+				return nil
+			}
+			v := &BridgeVlan{Ifindex: ifindex}
+			vlans = append(vlans, v)
+			return v.Unmarshal(nil, buf)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vlans) != 1 {
+		t.Fatalf("expected 1 vlan, found %d", len(vlans))
+	}
+
+	v := vlans[0]
+	if v.Ifindex != 5 || v.Vid != 10 || v.Range != 20 || v.Flags != 0 {
+		t.Errorf("unexpected vlan %+v", v)
+	}
+	if !v.Contains(15) || v.Contains(21) {
+		t.Error("unexpected vlan range")
+	}
+	if v.State == nil || *v.State != BR_STATE_FORWARDING {
+		t.Errorf("state %v", v.State)
+	}
+	if v.McastRouter == nil || *v.McastRouter != 1 {
+		t.Errorf("mcast router %v", v.McastRouter)
+	}
+	if v.NeighSuppress == nil || !*v.NeighSuppress {
+		t.Errorf("neigh suppress %v", v.NeighSuppress)
+	}
+	if v.McastGroups != 2 {
+		t.Errorf("mcast groups %d", v.McastGroups)
+	}
+	if v.Stats == nil || *v.Stats != (BridgeVlanStats{1000, 10, 2000, 20}) {
+		t.Errorf("stats %+v", v.Stats)
+	}
+
+}
+
+func Test_BridgeVlan(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	br := &Link{
+		Info: &LinkInfo{
+			Name:   "brvl47",
+			Bridge: &Bridge{VlanAware: true},
+		},
+	}
+	err = br.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Del(ctx)
+
+	port := &Link{
+		Info: &LinkInfo{
+			Name:   "brvlp47",
+			Master: uint32(br.Msg.Index),
+			Dummy:  &Dummy{},
+		},
+	}
+	err = port.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer port.Del(ctx)
+
+	// a pvid can not be given to a range
+	err = (&BridgeVlan{
+		Ifindex: uint32(port.Msg.Index),
+		Vid:     10,
+		Range:   20,
+		Flags:   BRIDGE_VLAN_INFO_PVID,
+	}).Add(ctx)
+	if err == nil {
+		t.Fatal("expected pvid on a vlan range to fail")
+	}
+
+	state := BR_STATE_BLOCKING
+	bv := &BridgeVlan{
+		Ifindex: uint32(port.Msg.Index),
+		Vid:     10,
+		Range:   20,
+		State:   &state,
+	}
+	err = bv.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vlans, err := ReadBridgeVlans(ctx, &BridgeVlan{
+		Ifindex: uint32(port.Msg.Index),
+		Vid:     15,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vlans) != 1 {
+		t.Fatalf("expected 1 vlan entry, found %d", len(vlans))
+	}
+	if vlans[0].Vid != 10 || vlans[0].Range != 20 {
+		t.Errorf("unexpected vlan range %d-%d", vlans[0].Vid, vlans[0].Range)
+	}
+	if vlans[0].State == nil || *vlans[0].State != BR_STATE_BLOCKING {
+		t.Errorf("state %v", vlans[0].State)
+	}
+
+	err = bv.Del(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vlans, err = ReadBridgeVlans(ctx, &BridgeVlan{
+		Ifindex: uint32(port.Msg.Index),
+		Vid:     15,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vlans) != 0 {
+		t.Errorf("vlan still present after delete %+v", vlans[0])
+	}
+
+}
+
+// an RTM_NEWMDB message as dumped by the kernel for the bridge with index 4,
+// holding a permanent ipv4 group and an igmpv3 style ipv6 group on port 6,
+// followed by the bridge's multicast router ports
+var kernelNewMdbMsg = []byte{
+	0x07, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, // br_port_msg
+	0x98, 0x00, 0x01, 0x00, // MDBA_MDB
+	0x34, 0x00, 0x01, 0x00, // MDBA_MDB_ENTRY
+	0x30, 0x00, 0x01, 0x00, // MDBA_MDB_ENTRY_INFO
+	0x06, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0a, 0x00, // br_mdb_entry
+	0xef, 0x01, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x08, 0x00, 0x00, 0x00,
+	0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // MDBA_MDB_EATTR_TIMER
+	0x05, 0x00, 0x05, 0x00, 0x03, 0x00, 0x00, 0x00, // MDBA_MDB_EATTR_RTPROT
+	0x60, 0x00, 0x01, 0x00, // MDBA_MDB_ENTRY
+	0x5c, 0x00, 0x01, 0x00, // MDBA_MDB_ENTRY_INFO
+	0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // br_mdb_entry
+	0xff, 0x0e, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+	0x86, 0xdd, 0x00, 0x00,
+	0x08, 0x00, 0x01, 0x00, 0x10, 0x27, 0x00, 0x00, // MDBA_MDB_EATTR_TIMER
+	0x24, 0x00, 0x02, 0x00, // MDBA_MDB_EATTR_SRC_LIST
+	0x20, 0x00, 0x01, 0x00, // MDBA_MDB_SRCLIST_ENTRY
+	0x14, 0x00, 0x01, 0x00, // MDBA_MDB_SRCATTR_ADDRESS
+	0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+	0x08, 0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x00, // MDBA_MDB_SRCATTR_TIMER
+	0x05, 0x00, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, // MDBA_MDB_EATTR_GROUP_MODE
+	0x05, 0x00, 0x05, 0x00, 0x03, 0x00, 0x00, 0x00, // MDBA_MDB_EATTR_RTPROT
+	0x1c, 0x00, 0x02, 0x00, // MDBA_ROUTER
+	0x18, 0x00, 0x01, 0x00, // MDBA_ROUTER_PORT
+	0x06, 0x00, 0x00, 0x00,
+	0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // MDBA_ROUTER_PATTR_TIMER
+	0x05, 0x00, 0x02, 0x00, 0x02, 0x00, 0x00, 0x00, // MDBA_ROUTER_PATTR_TYPE
+}
+
+func Test_MdbUnmarshal(t *testing.T) {
+
+	var entries []*MdbEntry
+	var routers []*MdbRouterPort
+	err := unmarshalBrPortMsg(kernelNewMdbMsg,
+		func(bridge uint32, typ uint16, buf []byte) error {
+			switch typ {
+			case MDBA_MDB:
+				es, err := unmarshalMdb(nil, bridge, buf)
+				entries = append(entries, es...)
+				return err
+			case MDBA_ROUTER:
+				rs, err := unmarshalMdbRouters(bridge, buf)
+				routers = append(routers, rs...)
+				return err
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, found %d", len(entries))
+	}
+
+	e := entries[0]
+	if e.Bridge != 4 || e.Port != 6 || e.Vid != 10 || !e.Permanent {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if !e.Group.Equal(net.ParseIP("239.1.1.1")) {
+		t.Errorf("group %s", e.Group)
+	}
+
+	e = entries[1]
+	if e.Bridge != 4 || e.Port != 6 || e.Permanent || e.Timer != 10000 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if !e.Group.Equal(net.ParseIP("ff0e::1")) {
+		t.Errorf("group %s", e.Group)
+	}
+	if e.GroupMode != MCAST_INCLUDE || len(e.Sources) != 1 {
+		t.Fatalf("unexpected igmpv3 state %+v", e)
+	}
+	if !e.Sources[0].Address.Equal(net.ParseIP("fd00::1")) || e.Sources[0].Timer != 100 {
+		t.Errorf("source %+v", e.Sources[0])
+	}
+
+	if len(routers) != 1 {
+		t.Fatalf("expected 1 router port, found %d", len(routers))
+	}
+	if *routers[0] != (MdbRouterPort{Bridge: 4, Port: 6, Type: 2}) {
+		t.Errorf("router port %+v", routers[0])
+	}
+
+}
+
+func Test_Mdb(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	br := &Link{
+		Info: &LinkInfo{
+			Name:   "brmdb47",
+			Bridge: &Bridge{},
+		},
+	}
+	err = br.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Del(ctx)
+
+	port := &Link{
+		Info: &LinkInfo{
+			Name:   "brmdbp47",
+			Master: uint32(br.Msg.Index),
+			Dummy:  &Dummy{},
+		},
+	}
+	err = port.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer port.Del(ctx)
+
+	// the kernel only accepts entries for running bridges and ports
+	err = br.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = port.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &MdbEntry{
+		Bridge:    uint32(br.Msg.Index),
+		Port:      uint32(port.Msg.Index),
+		Group:     net.ParseIP("239.4.7.1"),
+		Permanent: true,
+	}
+	err = AddMdb(ctx, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &MdbEntry{Bridge: uint32(br.Msg.Index), Group: e.Group}
+	entries, err := ReadMdb(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, found %d", len(entries))
+	}
+	if entries[0].Port != e.Port || !entries[0].Permanent {
+		t.Errorf("unexpected entry %+v", entries[0])
+	}
+
+	err = DelMdb(ctx, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err = ReadMdb(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("entry still present after delete %+v", entries[0])
+	}
+
+}
+
+func Test_Vxlan(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lo, err := GetLink(ctx, "lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the kernel may default the udp checksum to on, so it is turned off
+	// explicitly here
+	udpCsum := false
+	vx := &Link{
+		Info: &LinkInfo{
+			Name: "vtep47",
+			Vxlan: &Vxlan{
+				Vni:     47,
+				DstPort: 4789,
+				Local:   net.ParseIP("1.2.3.4"),
+				Link:    uint32(lo.Msg.Index),
+				UdpCsum: &udpCsum,
+			},
+		},
+	}
+
+	err = vx.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vx.Del(ctx)
+
+	xv, err := GetLink(ctx, "vtep47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xv.Info.Vxlan == nil {
+		t.Fatal("no vxlan data")
+	}
+
+	// only compare what was set, the kernel fills in defaults for the rest
+	v := xv.Info.Vxlan
+	if v.Vni != 47 || v.DstPort != 4789 || v.Link != uint32(lo.Msg.Index) {
+		t.Errorf("unexpected vxlan %+v", v)
+	}
+	if !v.Local.Equal(net.ParseIP("1.2.3.4")) {
+		t.Errorf("local %s", v.Local)
+	}
+	if v.UdpCsum == nil || *v.UdpCsum {
+		t.Errorf("udp csum %v", v.UdpCsum)
+	}
+
+}
+
+func Test_Vxlan6(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	zeroCsum := true
+	vx := &Link{
+		Info: &LinkInfo{
+			Name: "vtep6x47",
+			Vxlan: &Vxlan{
+				Vni:            4747,
+				DstPort:        4789,
+				Local:          net.ParseIP("fd00:47::1"),
+				Group:          net.ParseIP("fd00:47::2"),
+				Ttl:            64,
+				Tos:            1,
+				Ageing:         600,
+				Limit:          100,
+				PortRange:      &VxlanPortRange{Low: 49152, High: 50000},
+				Proxy:          true,
+				L2miss:         true,
+				L3miss:         true,
+				UdpZeroCsum6Tx: &zeroCsum,
+				UdpZeroCsum6Rx: &zeroCsum,
+				Label:          0x47,
+			},
+		},
+	}
+
+	err = vx.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vx.Del(ctx)
+
+	xv, err := GetLink(ctx, "vtep6x47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := xv.Info.Vxlan
+	if v == nil {
+		t.Fatal("no vxlan data")
+	}
+
+	if !v.Local.Equal(net.ParseIP("fd00:47::1")) {
+		t.Errorf("local6 %s", v.Local)
+	}
+	if !v.Group.Equal(net.ParseIP("fd00:47::2")) {
+		t.Errorf("group6 %s", v.Group)
+	}
+	if v.Vni != 4747 || v.Ttl != 64 || v.Tos != 1 || v.Ageing != 600 ||
+		v.Limit != 100 || v.Label != 0x47 {
+		t.Errorf("unexpected vxlan %+v", v)
+	}
+	if v.PortRange == nil || *v.PortRange != (VxlanPortRange{49152, 50000}) {
+		t.Errorf("port range %v", v.PortRange)
+	}
+	if !v.Proxy || !v.L2miss || !v.L3miss {
+		t.Errorf("proxy %v l2miss %v l3miss %v", v.Proxy, v.L2miss, v.L3miss)
+	}
+	if v.UdpZeroCsum6Tx == nil || !*v.UdpZeroCsum6Tx ||
+		v.UdpZeroCsum6Rx == nil || !*v.UdpZeroCsum6Rx {
+		t.Errorf("zero csum6 tx %v rx %v", v.UdpZeroCsum6Tx, v.UdpZeroCsum6Rx)
+	}
+
+}
+
+func Test_Geneve(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	gn := &Link{
+		Info: &LinkInfo{
+			Name: "gnv47",
+			Geneve: &Geneve{
+				Vni:     47,
+				Remote:  net.ParseIP("1.2.3.4"),
+				Ttl:     64,
+				DstPort: 6081,
+				Df:      GENEVE_DF_SET,
+			},
+		},
+	}
+
+	err = gn.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gn.Del(ctx)
+
+	ng, err := GetLink(ctx, "gnv47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ng.Info.Geneve == nil {
+		t.Fatal("no geneve data")
+	}
+
+	expected, actual := gn.Info.Geneve, ng.Info.Geneve
+	if expected.Vni != actual.Vni ||
+		!expected.Remote.Equal(actual.Remote) ||
+		expected.Ttl != actual.Ttl ||
+		expected.DstPort != actual.DstPort ||
+		expected.Df != actual.Df {
+		t.Error("geneves do not match")
+		t.Logf("expected: %#v", *expected)
+		t.Logf("actual: %#v", *actual)
+	}
+
+}
+
+func Test_Tap(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	owner := uint32(47)
+	tap := &Link{
+		Info: &LinkInfo{
+			Name: "tap47",
+			Tap: &Tap{TunTap: TunTap{
+				Owner:      &owner,
+				VnetHdr:    true,
+				MultiQueue: true,
+				NumQueues:  2,
+				KeepQueues: true,
+			}},
+		},
+	}
+	err = tap.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tap.Del(ctx)
+
+	if tap.Info.Tap == nil {
+		t.Fatal("tap read back as another link type")
+	}
+	if len(tap.Info.Tap.Queues) != 2 {
+		t.Fatalf("expected 2 queues, got %d", len(tap.Info.Tap.Queues))
+	}
+	for _, q := range tap.Info.Tap.Queues {
+		q.Close()
+	}
+
+	rt, err := GetLink(ctx, "tap47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Info.Tap == nil || !rt.Info.Tap.Persist || !rt.Info.Tap.VnetHdr {
+		t.Fatalf("tap properties not read back %+v", rt.Info.Tap)
+	}
+	if rt.Info.Tap.Owner == nil || *rt.Info.Tap.Owner != owner {
+		t.Fatal("tap owner not read back")
+	}
+	if rt.Info.Tap.NumQueues != 0 {
+		t.Errorf("creation queue count filled in on read %d", rt.Info.Tap.NumQueues)
+	}
+
+	// the tap is set through rtnetlink without sending tun attributes
+	err = rt.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func Test_Wg(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	ctx, err = OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create wg link
+
+	lnk := &Link{
+		Info: &LinkInfo{
+			Name:      "wgtest",
+			Wireguard: &Wireguard{},
+		},
+	}
+
+	err = lnk.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lnk.Absent(ctx)
+
+	// fetch wg link
+
+	wl, err := GetLink(ctx, "wgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wl.Info.Wireguard == nil {
+		t.Error("exepcted wireguard link")
+	}
+
+	// configure the device over generic netlink
+
+	var priv, pub WireguardKey
+	priv[0], pub[0] = 47, 74
+	port := uint16(4747)
+	_, ipn, _ := net.ParseCIDR("10.47.0.0/16")
+
+	dev := &WireguardDevice{
+		Name:       "wgtest",
+		PrivateKey: &priv,
+		ListenPort: &port,
+		Peers: []WireguardPeer{{
+			PublicKey:  pub,
+			Endpoint:   &net.UDPAddr{IP: net.ParseIP("192.168.47.1"), Port: 4747},
+			AllowedIPs: []net.IPNet{*ipn},
+		}},
+	}
+	err = dev.Set(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rd, err := ReadWireguardDevice(ctx, "wgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rd.ListenPort == nil || *rd.ListenPort != port {
+		t.Fatal("listen port not read back")
+	}
+	if len(rd.Peers) != 1 || rd.Peers[0].PublicKey != pub {
+		t.Fatalf("peer not read back %+v", rd.Peers)
+	}
+	if len(rd.Peers[0].AllowedIPs) != 1 ||
+		rd.Peers[0].AllowedIPs[0].String() != ipn.String() {
+		t.Fatalf("allowed ips not read back %v", rd.Peers[0].AllowedIPs)
+	}
+
+}
+
+func Test_GenlFamily(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	f, err := ctx.GenlFamily("nlctrl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Id != GENL_ID_CTRL {
+		t.Fatalf("nlctrl family id %d != %d", f.Id, GENL_ID_CTRL)
+	}
+	if _, err := f.McastGroup("notify"); err != nil {
+		t.Fatal(err)
+	}
+
+	// resolved families are cached on the context
+	g, err := ctx.GenlFamily("nlctrl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != g {
+		t.Fatal("family not cached")
+	}
+
+}
+
+func Test_Vrf(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	vrf := &Link{
+		Info: &LinkInfo{
+			Name: "vrf47",
+			Vrf:  &Vrf{Table: 47},
+		},
+	}
+	err = vrf.Present(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vrf.Absent(ctx)
+
+	va := &Link{
+		Info: &LinkInfo{
+			Name: "vethA",
+			Veth: &Veth{Peer: "vethB"},
+		},
+	}
+	err = va.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer va.Del(ctx)
+
+	err = VrfEnslave(ctx, "vrf47", "vethA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := VrfMembers(ctx, "vrf47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].Info.Name != "vethA" {
+		t.Fatalf("unexpected vrf members %v", members)
+	}
+
+	lnk, err := GetLink(ctx, "vethA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lnk.Info.VrfPort == nil || lnk.Info.VrfPort.Table != 47 {
+		t.Fatalf("vrf port table not read back %+v", lnk.Info.VrfPort)
+	}
+
+	err = EnsureL3mdevRule(ctx, unix.AF_INET)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = VrfRelease(ctx, "vethA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	members, err = VrfMembers(ctx, "vrf47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 0 {
+		t.Fatal("vrf member not released")
+	}
+
+}
+
+func Test_MacvlanSource(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	va := &Link{
+		Info: &LinkInfo{
+			Name: "vethA",
+			Veth: &Veth{Peer: "vethB"},
+		},
+	}
+	err = va.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer va.Del(ctx)
+
+	a, _ := net.ParseMAC("02:00:00:00:00:47")
+	b, _ := net.ParseMAC("02:00:00:00:00:74")
+
+	mv := &Link{
+		Info: &LinkInfo{
+			Name: "mv47",
+			Macvlan: &Macvlan{
+				Mode:       MACVLAN_MODE_SOURCE,
+				Link:       uint32(va.Msg.Index),
+				Flags:      MACVLAN_FLAG_NOPROMISC,
+				SourceMacs: []net.HardwareAddr{a},
+			},
+		},
+	}
+	err = mv.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mv.Del(ctx)
+
+	err = mv.AddMacvlanSource(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rv, err := GetLink(ctx, "mv47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv.Info.Macvlan.Flags&MACVLAN_FLAG_NOPROMISC == 0 {
+		t.Fatal("nopromisc flag not read back")
+	}
+	if len(rv.Info.Macvlan.SourceMacs) != 2 {
+		t.Fatalf("expected 2 source addresses, got %v", rv.Info.Macvlan.SourceMacs)
+	}
+
+	err = mv.FlushMacvlanSources(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rv, err = GetLink(ctx, "mv47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rv.Info.Macvlan.SourceMacs) != 0 {
+		t.Fatal("source addresses not flushed")
+	}
+
+	// flags are always sent on a set, so they can be cleared
+	err = mv.SetMacvlanFlags(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rv, err = GetLink(ctx, "mv47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv.Info.Macvlan.Flags&MACVLAN_FLAG_NOPROMISC != 0 {
+		t.Fatal("nopromisc flag not cleared")
+	}
+
+	err = mv.SetMacvlanFlags(ctx, MACVLAN_FLAG_NOPROMISC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rv, err = GetLink(ctx, "mv47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv.Info.Macvlan.Flags&MACVLAN_FLAG_NOPROMISC == 0 {
+		t.Fatal("nopromisc flag not set")
+	}
+
+}
+
+func Test_Dummy(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	for _, typ := range []LinkType{DummyType, IfbType, NlmonType} {
+
+		lnk := &Link{
+			Info: &LinkInfo{
+				Name: typ.String() + "47",
+			},
+		}
+		lnk.ApplyType(typ.String())
+
+		err = lnk.Add(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer lnk.Del(ctx)
+
+		rl, err := GetLink(ctx, typ.String()+"47")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rl.Info.Type() != typ {
+			t.Errorf("%s read back as %s", typ, rl.Info.Type())
+		}
+
+	}
+
+}
+
+func Test_XfrmVti(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	xf := &Link{
+		Info: &LinkInfo{
+			Name: "xfrm47",
+			Xfrm: &Xfrm{IfId: 47},
+		},
+	}
+	err = xf.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xf.Del(ctx)
+
+	rx, err := GetLink(ctx, "xfrm47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rx.Info.Xfrm == nil || rx.Info.Xfrm.IfId != 47 {
+		t.Errorf("unexpected xfrm read back %+v", rx.Info.Xfrm)
+	}
+
+	for _, v := range []*Vti{
+		{Local: net.ParseIP("10.47.0.1"), Remote: net.ParseIP("10.47.0.2"), Ikey: 4, Okey: 7},
+		{Local: net.ParseIP("fd47::1"), Remote: net.ParseIP("fd47::2"), Ikey: 4, Okey: 7},
+	} {
+
+		lnk := &Link{
+			Info: &LinkInfo{
+				Name: v.Kind() + "47",
+				Vti:  v,
+			},
+		}
+		err = lnk.Add(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rv, err := GetLink(ctx, v.Kind()+"47")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rv.Info.Vti == nil || rv.Info.Type().String() != v.Kind() {
+			t.Errorf("%s read back as %s", v.Kind(), rv.Info.Type())
+		} else {
+			if !rv.Info.Vti.Remote.Equal(v.Remote) {
+				t.Errorf("remote mismatch %s != %s", rv.Info.Vti.Remote, v.Remote)
+			}
+			if rv.Info.Vti.Ikey != 4 || rv.Info.Vti.Okey != 7 {
+				t.Errorf("key mismatch %d/%d", rv.Info.Vti.Ikey, rv.Info.Vti.Okey)
+			}
+		}
+
+		err = lnk.Del(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+	}
+
+}
+
+func Test_Macsec(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	parent := &Link{
+		Info: &LinkInfo{
+			Name:  "msparent47",
+			Dummy: &Dummy{},
+		},
+	}
+	err = parent.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parent.Del(ctx)
+
+	encrypt := true
+	lnk := &Link{
+		Info: &LinkInfo{
+			Name: "macsec47",
+			Macsec: &Macsec{
+				Link:    uint32(parent.Msg.Index),
+				Port:    47,
+				Encrypt: &encrypt,
+			},
+		},
+	}
+	err = lnk.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lnk.Del(ctx)
+
+	rl, err := GetLink(ctx, "macsec47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := rl.Info.Macsec
+	if ms == nil {
+		t.Fatal("macsec not read back")
+	}
+	if ms.Link != uint32(parent.Msg.Index) {
+		t.Errorf("link mismatch %d != %d", ms.Link, parent.Msg.Index)
+	}
+	if ms.Sci&0xffff != 47 {
+		t.Errorf("sci %016x does not carry port", ms.Sci)
+	}
+	if ms.Encrypt == nil || !*ms.Encrypt {
+		t.Error("encryption not enabled")
+	}
+
+	ifx := uint32(rl.Msg.Index)
+	sa := &MacsecSa{
+		An:  0,
+		Pn:  1,
+		Key: make([]byte, 16),
+	}
+	err = AddMacsecTxSa(ctx, ifx, sa)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peer := uint64(0x0200000000470001)
+	err = AddMacsecRxSc(ctx, ifx, peer, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = AddMacsecRxSa(ctx, ifx, peer, sa)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = DelMacsecRxSa(ctx, ifx, peer, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = DelMacsecRxSc(ctx, ifx, peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = DelMacsecTxSa(ctx, ifx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func Test_MacsecSciUnmarshal(t *testing.T) {
+
+	// the sci as the kernel sends it, a __be64 of link address and port
+	ae := netlink.NewAttributeEncoder()
+	ae.Bytes(IFLA_MACSEC_SCI, []byte{0x02, 0x47, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01})
+	buf, err := ae.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Macsec{}
+	err = m.Unmarshal(nil, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Sci != 0x0247000000010001 {
+		t.Errorf("unexpected sci %#x", m.Sci)
+	}
+
+	ae = netlink.NewAttributeEncoder()
+	ae.Bytes(IFLA_MACSEC_SCI, []byte{0x02, 0x47})
+	buf, err = ae.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = (&Macsec{}).Unmarshal(nil, buf)
+	if err == nil {
+		t.Error("expected an error for a short sci")
+	}
+
+}
+
+func Test_Vxcan(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	vcan := &Link{
+		Info: &LinkInfo{
+			Name: "vcan47",
+			Vcan: &Vcan{},
+		},
+	}
+	err = vcan.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vcan.Del(ctx)
+
+	rv, err := GetLink(ctx, "vcan47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv.Info.Type() != VcanType {
+		t.Errorf("vcan read back as %s", rv.Info.Type())
+	}
+
+	lnk := &Link{
+		Info: &LinkInfo{
+			Name:  "vxcan47",
+			Vxcan: &Vxcan{Veth: Veth{Peer: "vxcan48"}},
+		},
+	}
+	err = lnk.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lnk.Del(ctx)
+
+	rl, err := GetLink(ctx, "vxcan47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.Info.Vxcan == nil {
+		t.Fatalf("vxcan read back as %s", rl.Info.Type())
+	}
+	err = rl.Info.Vxcan.ResolvePeerNamespace(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.Info.Vxcan.Peer != "vxcan48" {
+		t.Errorf("unexpected peer %s", rl.Info.Vxcan.Peer)
+	}
+
+}
+
+func Test_XdpUnmarshal(t *testing.T) {
+
+	// IFLA_XDP as reported for a link with a program in generic mode
+	buf := []byte{
+		0x05, 0x00, 0x02, 0x00, 0x02, 0x00, 0x00, 0x00, // ATTACHED skb
+		0x08, 0x00, 0x06, 0x00, 0x2f, 0x00, 0x00, 0x00, // SKB_PROG_ID 47
+		0x08, 0x00, 0x04, 0x00, 0x2f, 0x00, 0x00, 0x00, // PROG_ID 47
+	}
+
+	x := &Xdp{}
+	err := x.Unmarshal(nil, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Attached != XDP_ATTACHED_SKB {
+		t.Errorf("attached as %s", x.Attached)
+	}
+	if x.ProgId != 47 || x.SkbProgId != 47 || x.DrvProgId != 0 {
+		t.Errorf("unexpected program ids %+v", x)
+	}
+
+}
+
+// vfInfoList is an IFLA_VFINFO_LIST holding a single vf, laid out as the
+// kernel reports it for a physical function with SR-IOV enabled
+var vfInfoList = []byte{
+	0xec, 0x00, 0x01, 0x00, // IFLA_VF_INFO
+	0x28, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, // IFLA_VF_MAC vf 0
+	0x02, 0x00, 0x00, 0x00, 0x47, 0x01, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x10, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, // IFLA_VF_VLAN 100 qos 3
+	0x64, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00,
+	0x10, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00, 0x00, // IFLA_VF_RATE 0-1000
+	0x00, 0x00, 0x00, 0x00, 0xe8, 0x03, 0x00, 0x00,
+	0x0c, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, // IFLA_VF_TX_RATE 1000
+	0xe8, 0x03, 0x00, 0x00,
+	0x0c, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, // IFLA_VF_SPOOFCHK on
+	0x01, 0x00, 0x00, 0x00,
+	0x0c, 0x00, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, // IFLA_VF_LINK_STATE disable
+	0x02, 0x00, 0x00, 0x00,
+	0x0c, 0x00, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, // IFLA_VF_RSS_QUERY_EN unsupported
+	0xff, 0xff, 0xff, 0xff,
+	0x0c, 0x00, 0x09, 0x00, 0x00, 0x00, 0x00, 0x00, // IFLA_VF_TRUST off
+	0x00, 0x00, 0x00, 0x00,
+	0x64, 0x00, 0x08, 0x00, // IFLA_VF_STATS
+	0x0c, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x0c, 0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x0c, 0x00, 0x02, 0x00, 0xe8, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x0c, 0x00, 0x03, 0x00, 0xd0, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x0c, 0x00, 0x04, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x0c, 0x00, 0x05, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x0c, 0x00, 0x07, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x0c, 0x00, 0x08, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+func Test_VfInfoUnmarshal(t *testing.T) {
+
+	vfs, err := unmarshalVfInfoList(vfInfoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vfs) != 1 {
+		t.Fatalf("expected 1 vf, got %d", len(vfs))
+	}
+	vf := vfs[0]
+
+	if vf.Mac.String() != "02:00:00:00:47:01" {
+		t.Errorf("unexpected mac %s", vf.Mac)
+	}
+	if vf.Vlan != 100 || vf.Qos != 3 {
+		t.Errorf("unexpected vlan %d qos %d", vf.Vlan, vf.Qos)
+	}
+	if vf.MinTxRate != 0 || vf.MaxTxRate != 1000 {
+		t.Errorf("unexpected rate %d-%d", vf.MinTxRate, vf.MaxTxRate)
+	}
+	if vf.SpoofChk == nil || !*vf.SpoofChk {
+		t.Error("expected spoof checking")
+	}
+	if vf.Trust == nil || *vf.Trust {
+		t.Error("expected untrusted vf")
+	}
+	if vf.RssQuery != nil {
+		t.Error("expected unsupported rss query")
+	}
+	if vf.LinkState != IFLA_VF_LINK_STATE_DISABLE {
+		t.Errorf("unexpected link state %s", vf.LinkState)
+	}
+	if vf.Stats == nil {
+		t.Fatal("expected stats")
+	}
+	if vf.Stats.RxPackets != 10 || vf.Stats.TxBytes != 2000 || vf.Stats.TxDropped != 4 {
+		t.Errorf("unexpected stats %+v", vf.Stats)
+	}
+
+}
+
+func Test_VfInfoMarshal(t *testing.T) {
+
+	// as sent by ip link set dev <pf> vf 1 vlan 100 qos 3
+	expected := []byte{
+		0x18, 0x00, 0x16, 0x00, // IFLA_VFINFO_LIST
+		0x14, 0x00, 0x01, 0x00, // IFLA_VF_INFO
+		0x10, 0x00, 0x02, 0x00, 0x01, 0x00, 0x00, 0x00, // IFLA_VF_VLAN vf 1
+		0x64, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00,
+	}
+
+	buf, err := vfInfoListBytes(IFLA_VF_VLAN, vfStruct(1, 100, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("unexpected encoding\n% x\n% x", buf, expected)
+	}
+
+}
+
+func Test_Devconf(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk := &Link{
+		Info: &LinkInfo{
+			Name:  "devconf47",
+			Dummy: &Dummy{},
+		},
+	}
+	err = lnk.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lnk.Del(ctx)
+
+	err = lnk.SetInet4Conf(ctx, map[Ipv4Devconf]uint32{
+		IPV4_DEVCONF_RP_FILTER: 2,
+		IPV4_DEVCONF_PROXY_ARP: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = lnk.SetInet6AddrGenMode(ctx, IN6_ADDR_GEN_MODE_NONE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rl, err := GetLink(ctx, "devconf47")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.Info.Inet4Conf[IPV4_DEVCONF_RP_FILTER] != 2 {
+		t.Errorf("rp_filter is %d", rl.Info.Inet4Conf[IPV4_DEVCONF_RP_FILTER])
+	}
+	if rl.Info.Inet4Conf[IPV4_DEVCONF_PROXY_ARP] != 1 {
+		t.Errorf("proxy_arp is %d", rl.Info.Inet4Conf[IPV4_DEVCONF_PROXY_ARP])
+	}
+	if rl.Info.Inet6 == nil || rl.Info.Inet6.AddrGenMode != IN6_ADDR_GEN_MODE_NONE {
+		t.Errorf("unexpected inet6 info %+v", rl.Info.Inet6)
+	}
+	if _, ok := rl.Info.Inet6Conf[DEVCONF_DISABLE_IPV6]; !ok {
+		t.Error("inet6 devconf not decoded")
+	}
+
+}
+
+func Test_Netconf(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	all, err := ReadNetconf(ctx, unix.AF_INET, NETCONFA_IFINDEX_ALL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Ifindex != NETCONFA_IFINDEX_ALL || all.Forwarding == nil {
+		t.Errorf("unexpected all netconf %+v", all)
+	}
+
+	// the groups are joined before any change below is made
+	w, err := NewWatcher(ctx, []uint32{RTNLGRP_LINK, RTNLGRP_IPV4_NETCONF})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	events := make(chan Event, 64)
+	done := make(chan struct{})
+	watched := make(chan error, 1)
+	go func() {
+		watched <- w.Run(events, done)
+	}()
+
+	lnk := &Link{
+		Info: &LinkInfo{
+			Name:  "netconf47",
+			Dummy: &Dummy{},
+		},
+	}
+	err = lnk.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lnk.Del(ctx)
+
+	// this writes the sysctl rather than using SetInet4Conf because the kernel
+	// only sends netconf notifications for sysctl writes, devconf changes made
+	// through rtnetlink are applied silently
+	err = ioutil.WriteFile(
+		"/proc/sys/net/ipv4/conf/netconf47/rp_filter", []byte("2"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sawLink, sawNetconf bool
+	timeout := time.After(2 * time.Second)
+	for !sawLink || !sawNetconf {
+		select {
+		case ev := <-events:
+			if ev.Link != nil && ev.Link.Info.Name == "netconf47" {
+				sawLink = true
+			}
+			if ev.Netconf != nil && ev.Netconf.Ifindex == lnk.Msg.Index &&
+				ev.Netconf.RpFilter != nil && *ev.Netconf.RpFilter == 2 {
+				sawNetconf = true
+			}
+		case <-timeout:
+			t.Fatalf("missing events link=%v netconf=%v", sawLink, sawNetconf)
+		}
+	}
+
+	nc, err := ReadNetconf(ctx, unix.AF_INET, lnk.Msg.Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nc.RpFilter == nil || *nc.RpFilter != 2 {
+		t.Errorf("unexpected rp_filter %v", nc.RpFilter)
+	}
+
+	close(done)
+	err = <-watched
+	if err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func Test_AddrPresentAbsent(t *testing.T) {
+
+	ctx, err := OpenDefaultContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	lnk := &Link{
+		Info: &LinkInfo{
+			Name:  "addr48",
+			Dummy: &Dummy{},
+		},
+	}
+	err = lnk.Add(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lnk.Del(ctx)
+
+	addr, err := ParseAddr("10.48.0.1/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr.Msg.Index = uint32(lnk.Msg.Index)
+
+	for i := 0; i < 2; i++ {
+		err = addr.Present(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = addr.Replace(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addrs, err := lnk.Addrs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 {
+		t.Fatalf("expected 1 address, found %d", len(addrs))
+	}
+
+	for i := 0; i < 2; i++ {
+		err = addr.Absent(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	addrs, err = lnk.Addrs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 0 {
+		t.Fatalf("expected no addresses, found %d", len(addrs))
+	}
+
+}
+
+func Test_AddrFlags(t *testing.T) {
+
+	addr, err := ParseAddr("fd00:49::1/64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr.Msg.Index = 47
+	addr.Info.Nodad = true
+	addr.Info.NoPrefixRoute = true
+	addr.Info.CacheInfo = &AddrCacheInfo{Preferred: 300, Valid: 600}
+
+	buf, err := addr.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := &Address{}
+	err = rt.Unmarshal(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !rt.Info.Nodad || !rt.Info.NoPrefixRoute || rt.Info.ManageTempAddr {
+		t.Errorf("unexpected flags %+v", rt.Info)
+	}
+	if rt.Msg.Flags != unix.IFA_F_NODAD {
+		t.Errorf("unexpected header flags %#x", rt.Msg.Flags)
+	}
+	if rt.Info.CacheInfo == nil ||
+		rt.Info.CacheInfo.Preferred != 300 || rt.Info.CacheInfo.Valid != 600 {
+		t.Errorf("unexpected cache info %+v", rt.Info.CacheInfo)
+	}
+
+	// clearing a flag must drop it even if the header still carries it
+	rt.Info.Nodad = false
+	buf, err = rt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rt.Unmarshal(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Info.Nodad || !rt.Info.NoPrefixRoute {
+		t.Errorf("unexpected flags after clear %+v", rt.Info)
+	}
+
+}
+
+// address messages as produced by the kernel for ipv4 point-to-point and
+// broadcast addresses, attributes in inet_fill_ifaddr order
+var kernelAddrMsgs = []struct {
+	name      string
+	buf       []byte
+	address   string
+	local     string
+	broadcast string
+}{
+	{
+		name: "peer",
+		buf: []byte{
+			0x02, 0x20, 0x80, 0x00, 0x07, 0x00, 0x00, 0x00, // ifaddrmsg
+			0x08, 0x00, 0x01, 0x00, 0x0a, 0x00, 0x00, 0x02, // IFA_ADDRESS
+			0x08, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x00, 0x01, // IFA_LOCAL
+			0x09, 0x00, 0x03, 0x00, 'p', 't', 'p', '0', 0x00, 0x00, 0x00, 0x00, // IFA_LABEL
+			0x08, 0x00, 0x08, 0x00, 0x80, 0x00, 0x00, 0x00, // IFA_FLAGS
+			0x14, 0x00, 0x06, 0x00, // IFA_CACHEINFO
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+			0xe8, 0x03, 0x00, 0x00, 0xe8, 0x03, 0x00, 0x00,
+		},
+		address: "10.0.0.2/32",
+		local:   "10.0.0.1",
+	},
+	{
+		name: "broadcast",
+		buf: []byte{
+			0x02, 0x18, 0x80, 0x00, 0x02, 0x00, 0x00, 0x00, // ifaddrmsg
+			0x08, 0x00, 0x01, 0x00, 0xc0, 0xa8, 0x2f, 0x01, // IFA_ADDRESS
+			0x08, 0x00, 0x02, 0x00, 0xc0, 0xa8, 0x2f, 0x01, // IFA_LOCAL
+			0x08, 0x00, 0x04, 0x00, 0xc0, 0xa8, 0x2f, 0xff, // IFA_BROADCAST
+			0x09, 0x00, 0x03, 0x00, 'e', 't', 'h', '0', 0x00, 0x00, 0x00, 0x00, // IFA_LABEL
+			0x08, 0x00, 0x08, 0x00, 0x80, 0x00, 0x00, 0x00, // IFA_FLAGS
+			0x14, 0x00, 0x06, 0x00, // IFA_CACHEINFO
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+			0x10, 0x27, 0x00, 0x00, 0x10, 0x27, 0x00, 0x00,
+		},
+		address:   "192.168.47.1/24",
+		local:     "192.168.47.1",
+		broadcast: "192.168.47.255",
+	},
+}
+
+func Test_AddrKernelRoundTrip(t *testing.T) {
+
+	for _, m := range kernelAddrMsgs {
+
+		a := &Address{}
+		err := a.Unmarshal(m.buf)
+		if err != nil {
+			t.Fatalf("%s: %v", m.name, err)
+		}
+
+		if a.Info.Address.String() != m.address {
+			t.Errorf("%s: address %s", m.name, a.Info.Address)
+		}
+		if a.Info.Local.String() != m.local {
+			t.Errorf("%s: local %s", m.name, a.Info.Local)
+		}
+		if m.broadcast != "" && a.Info.Broadcast.String() != m.broadcast {
+			t.Errorf("%s: broadcast %s", m.name, a.Info.Broadcast)
+		}
+
+		buf, err := a.Marshal()
+		if err != nil {
+			t.Fatalf("%s: %v", m.name, err)
+		}
+		if !bytes.Equal(buf, m.buf) {
+			t.Errorf("%s: round trip mismatch\n% x\n% x", m.name, buf, m.buf)
+		}
+
+	}
+
+}
+
+func Test_AddrIpv6PeerAnycast(t *testing.T) {
+
+	// ipv6 point-to-point address in inet6_fill_ifaddr order
+	peer := []byte{
+		0x0a, 0x80, 0x80, 0x00, 0x09, 0x00, 0x00, 0x00, // ifaddrmsg
+		0x14, 0x00, 0x02, 0x00, // IFA_LOCAL
+		0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+		0x14, 0x00, 0x01, 0x00, // IFA_ADDRESS
+		0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02,
+		0x14, 0x00, 0x06, 0x00, // IFA_CACHEINFO
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xe8, 0x03, 0x00, 0x00, 0xe8, 0x03, 0x00, 0x00,
+		0x08, 0x00, 0x08, 0x00, 0x80, 0x00, 0x00, 0x00, // IFA_FLAGS
+	}
+
+	// ipv6 anycast address in inet6_fill_ifacaddr order
+	anycast := []byte{
+		0x0a, 0x80, 0x80, 0x00, 0x09, 0x00, 0x00, 0x00, // ifaddrmsg
+		0x14, 0x00, 0x05, 0x00, // IFA_ANYCAST
+		0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x14, 0x00, 0x06, 0x00, // IFA_CACHEINFO
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xe8, 0x03, 0x00, 0x00, 0xe8, 0x03, 0x00, 0x00,
+	}
+
+	check := func(a *Address) {
+		if a.Info.Address.String() != "fd00::2/128" {
+			t.Errorf("address %s", a.Info.Address)
+		}
+		if a.Info.Local.String() != "fd00::1" {
+			t.Errorf("local %s", a.Info.Local)
+		}
+	}
+
+	a := &Address{}
+	err := a.Unmarshal(peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(a)
+
+	buf, err := a.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := &Address{}
+	err = rt.Unmarshal(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(rt)
+
+	ac := &Address{}
+	err = ac.Unmarshal(anycast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ac.Info.Anycast.String() != "fd00::" {
+		t.Errorf("anycast %s", ac.Info.Anycast)
+	}
+
+	ac.Info.Address = &net.IPNet{IP: net.ParseIP("fd00::1"), Mask: net.CIDRMask(64, 128)}
+	buf, err = ac.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt = &Address{}
+	err = rt.Unmarshal(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rt.Info.Anycast.Equal(ac.Info.Anycast) {
+		t.Errorf("anycast round trip %s", rt.Info.Anycast)
 	}
 
 }
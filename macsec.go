@@ -0,0 +1,506 @@
+package rtnl
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// macsec attribute types
+const (
+	IFLA_MACSEC_UNSPEC uint16 = iota
+	IFLA_MACSEC_SCI
+	IFLA_MACSEC_PORT
+	IFLA_MACSEC_ICV_LEN
+	IFLA_MACSEC_CIPHER_SUITE
+	IFLA_MACSEC_WINDOW
+	IFLA_MACSEC_ENCODING_SA
+	IFLA_MACSEC_ENCRYPT
+	IFLA_MACSEC_PROTECT
+	IFLA_MACSEC_INC_SCI
+	IFLA_MACSEC_ES
+	IFLA_MACSEC_SCB
+	IFLA_MACSEC_REPLAY_PROTECT
+	IFLA_MACSEC_VALIDATION
+	IFLA_MACSEC_PAD
+	IFLA_MACSEC_OFFLOAD
+)
+
+// macsec cipher suites
+const (
+	MACSEC_CIPHER_ID_GCM_AES_128     uint64 = 0x0080C20001000001
+	MACSEC_CIPHER_ID_GCM_AES_256     uint64 = 0x0080C20001000002
+	MACSEC_CIPHER_ID_GCM_AES_XPN_128 uint64 = 0x0080C20001000003
+	MACSEC_CIPHER_ID_GCM_AES_XPN_256 uint64 = 0x0080C20001000004
+)
+
+// MacsecValidation aliases macsec frame validation modes in a type safe way
+type MacsecValidation uint8
+
+const (
+	MACSEC_VALIDATE_DISABLED MacsecValidation = iota
+	MACSEC_VALIDATE_CHECK
+	MACSEC_VALIDATE_STRICT
+)
+
+// MacsecOffload aliases macsec offload modes in a type safe way
+type MacsecOffload uint8
+
+const (
+	MACSEC_OFFLOAD_OFF MacsecOffload = iota
+	MACSEC_OFFLOAD_PHY
+	MACSEC_OFFLOAD_MAC
+)
+
+// macsec generic netlink commands
+const (
+	MACSEC_CMD_GET_TXSC uint8 = iota
+	MACSEC_CMD_ADD_RXSC
+	MACSEC_CMD_DEL_RXSC
+	MACSEC_CMD_UPD_RXSC
+	MACSEC_CMD_ADD_TXSA
+	MACSEC_CMD_DEL_TXSA
+	MACSEC_CMD_UPD_TXSA
+	MACSEC_CMD_ADD_RXSA
+	MACSEC_CMD_DEL_RXSA
+	MACSEC_CMD_UPD_RXSA
+	MACSEC_CMD_UPD_OFFLOAD
+)
+
+// macsec generic netlink attribute types
+const (
+	MACSEC_ATTR_UNSPEC uint16 = iota
+	MACSEC_ATTR_IFINDEX
+	MACSEC_ATTR_RXSC_CONFIG
+	MACSEC_ATTR_SA_CONFIG
+	MACSEC_ATTR_SECY
+	MACSEC_ATTR_TXSA_LIST
+	MACSEC_ATTR_RXSC_LIST
+	MACSEC_ATTR_TXSC_STATS
+	MACSEC_ATTR_SECY_STATS
+	MACSEC_ATTR_OFFLOAD
+)
+
+// macsec receive channel attribute types
+const (
+	MACSEC_RXSC_ATTR_UNSPEC uint16 = iota
+	MACSEC_RXSC_ATTR_SCI
+	MACSEC_RXSC_ATTR_ACTIVE
+	MACSEC_RXSC_ATTR_SA_LIST
+	MACSEC_RXSC_ATTR_STATS
+	MACSEC_RXSC_ATTR_PAD
+)
+
+// macsec security association attribute types
+const (
+	MACSEC_SA_ATTR_UNSPEC uint16 = iota
+	MACSEC_SA_ATTR_AN
+	MACSEC_SA_ATTR_ACTIVE
+	MACSEC_SA_ATTR_PN
+	MACSEC_SA_ATTR_KEY
+	MACSEC_SA_ATTR_KEYID
+	MACSEC_SA_ATTR_STATS
+	MACSEC_SA_ATTR_PAD
+	MACSEC_SA_ATTR_SSCI
+	MACSEC_SA_ATTR_SALT
+)
+
+const (
+	macsecFamilyName = "macsec"
+	sciLen           = 8
+
+	MACSEC_KEYID_LEN = 16
+	MACSEC_SALT_LEN  = 12
+)
+
+// Macsec encapsulates information about macsec links, which protect the
+// traffic of the link they are created on. Pointer fields are only sent to the
+// kernel when set, leaving the kernel defaults in place otherwise.
+type Macsec struct {
+	Link uint32 // interface index
+
+	// the secure channel identifier, derived from the link address and port
+	// when zero
+	Sci  uint64
+	Port uint16
+
+	IcvLen        uint8
+	CipherSuite   uint64
+	EncodingSa    uint8
+	Encrypt       *bool
+	Protect       *bool
+	IncludeSci    *bool
+	EndStation    *bool
+	Scb           *bool
+	ReplayProtect *bool
+	Window        uint32
+	Validation    *MacsecValidation
+	Offload       MacsecOffload
+}
+
+// MacsecSa is a macsec secure association as programmed over generic netlink.
+type MacsecSa struct {
+	An     uint8
+	Active *bool
+
+	// the next packet number, extended packet numbers are 64 bits and are used
+	// with the xpn cipher suites
+	Pn  uint64
+	Xpn bool
+
+	Key   []byte
+	KeyId [MACSEC_KEYID_LEN]byte
+
+	// short secure channel identifier and salt for xpn cipher suites
+	Ssci uint32
+	Salt []byte
+}
+
+// Macsec ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// Marshal turns a macsec into a binary rtnetlink set of attributes.
+func (m *Macsec) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(unix.IFLA_LINK, m.Link)
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte("macsec"))
+		ae1.Do(IFLA_INFO_DATA, func() ([]byte, error) {
+
+			ae2 := netlink.NewAttributeEncoder()
+
+			// the kernel takes the port only when no sci is given
+			if m.Sci != 0 {
+				ae2.Bytes(IFLA_MACSEC_SCI, sciBytes(m.Sci))
+			} else if m.Port != 0 {
+				ae2.Uint16(IFLA_MACSEC_PORT, htons(m.Port))
+			}
+
+			if m.IcvLen != 0 {
+				ae2.Uint8(IFLA_MACSEC_ICV_LEN, m.IcvLen)
+			}
+			if m.CipherSuite != 0 {
+				ae2.Uint64(IFLA_MACSEC_CIPHER_SUITE, m.CipherSuite)
+			}
+			if m.Window != 0 {
+				ae2.Uint32(IFLA_MACSEC_WINDOW, m.Window)
+			}
+
+			ae2.Uint8(IFLA_MACSEC_ENCODING_SA, m.EncodingSa)
+
+			for _, x := range []struct {
+				typ uint16
+				val *bool
+			}{
+				{IFLA_MACSEC_ENCRYPT, m.Encrypt},
+				{IFLA_MACSEC_PROTECT, m.Protect},
+				{IFLA_MACSEC_INC_SCI, m.IncludeSci},
+				{IFLA_MACSEC_ES, m.EndStation},
+				{IFLA_MACSEC_SCB, m.Scb},
+				{IFLA_MACSEC_REPLAY_PROTECT, m.ReplayProtect},
+			} {
+				if x.val != nil {
+					ae2.Uint8(x.typ, boolByte(*x.val))
+				}
+			}
+
+			if m.Validation != nil {
+				ae2.Uint8(IFLA_MACSEC_VALIDATION, uint8(*m.Validation))
+			}
+			if m.Offload != MACSEC_OFFLOAD_OFF {
+				ae2.Uint8(IFLA_MACSEC_OFFLOAD, uint8(m.Offload))
+			}
+
+			return ae2.Encode()
+
+		})
+
+		return ae1.Encode()
+
+	})
+	attrbuf, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode macsec attributes")
+		return nil, err
+	}
+
+	return attrbuf, nil
+
+}
+
+// Unmarshal reads a macsec from a binary set of attributes.
+func (m *Macsec) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create macsec attribute decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_MACSEC_SCI:
+			buf := ad.Bytes()
+			if len(buf) < sciLen {
+				return fmt.Errorf("short macsec sci")
+			}
+			m.Sci = sciFromBytes(buf)
+
+		case IFLA_MACSEC_PORT:
+			m.Port = ntohs(ad.Uint16())
+
+		case IFLA_MACSEC_ICV_LEN:
+			m.IcvLen = ad.Uint8()
+
+		case IFLA_MACSEC_CIPHER_SUITE:
+			m.CipherSuite = ad.Uint64()
+
+		case IFLA_MACSEC_WINDOW:
+			m.Window = ad.Uint32()
+
+		case IFLA_MACSEC_ENCODING_SA:
+			m.EncodingSa = ad.Uint8()
+
+		case IFLA_MACSEC_ENCRYPT:
+			m.Encrypt = decodeBool(ad)
+
+		case IFLA_MACSEC_PROTECT:
+			m.Protect = decodeBool(ad)
+
+		case IFLA_MACSEC_INC_SCI:
+			m.IncludeSci = decodeBool(ad)
+
+		case IFLA_MACSEC_ES:
+			m.EndStation = decodeBool(ad)
+
+		case IFLA_MACSEC_SCB:
+			m.Scb = decodeBool(ad)
+
+		case IFLA_MACSEC_REPLAY_PROTECT:
+			m.ReplayProtect = decodeBool(ad)
+
+		case IFLA_MACSEC_VALIDATION:
+			v := MacsecValidation(ad.Uint8())
+			m.Validation = &v
+
+		case IFLA_MACSEC_OFFLOAD:
+			m.Offload = MacsecOffload(ad.Uint8())
+
+		}
+	}
+
+	return nil
+
+}
+
+// Resolve handle attributes
+func (m *Macsec) Resolve(ctx *Context) error {
+
+	return nil
+
+}
+
+func ParseMacsecValidation(mode string) (MacsecValidation, error) {
+
+	switch mode {
+	case "disabled":
+		return MACSEC_VALIDATE_DISABLED, nil
+	case "check":
+		return MACSEC_VALIDATE_CHECK, nil
+	case "strict":
+		return MACSEC_VALIDATE_STRICT, nil
+	}
+
+	return 0, fmt.Errorf("undefined macsec validation mode")
+
+}
+
+func ParseMacsecCipherSuite(suite string) (uint64, error) {
+
+	switch suite {
+	case "gcm-aes-128":
+		return MACSEC_CIPHER_ID_GCM_AES_128, nil
+	case "gcm-aes-256":
+		return MACSEC_CIPHER_ID_GCM_AES_256, nil
+	case "gcm-aes-xpn-128":
+		return MACSEC_CIPHER_ID_GCM_AES_XPN_128, nil
+	case "gcm-aes-xpn-256":
+		return MACSEC_CIPHER_ID_GCM_AES_XPN_256, nil
+	}
+
+	return 0, fmt.Errorf("undefined macsec cipher suite")
+
+}
+
+// Secure channels and associations ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// AddMacsecTxSa adds a transmit secure association to the macsec link with the
+// provided index.
+func AddMacsecTxSa(ctx *Context, ifindex uint32, sa *MacsecSa) error {
+
+	return macsecCommand(ctx, MACSEC_CMD_ADD_TXSA, ifindex, nil, nil, sa, true)
+
+}
+
+// UpdateMacsecTxSa changes the activity and packet number of a transmit
+// secure association. Keys cannot be changed in place.
+func UpdateMacsecTxSa(ctx *Context, ifindex uint32, sa *MacsecSa) error {
+
+	return macsecCommand(ctx, MACSEC_CMD_UPD_TXSA, ifindex, nil, nil, sa, false)
+
+}
+
+// DelMacsecTxSa removes the transmit secure association with the provided
+// association number.
+func DelMacsecTxSa(ctx *Context, ifindex uint32, an uint8) error {
+
+	return macsecCommand(
+		ctx, MACSEC_CMD_DEL_TXSA, ifindex, nil, nil, &MacsecSa{An: an}, false)
+
+}
+
+// AddMacsecRxSc adds a receive secure channel for the peer with the provided
+// sci to the macsec link with the provided index.
+func AddMacsecRxSc(ctx *Context, ifindex uint32, sci uint64, active bool) error {
+
+	return macsecCommand(
+		ctx, MACSEC_CMD_ADD_RXSC, ifindex, &sci, &active, nil, false)
+
+}
+
+// UpdateMacsecRxSc changes the activity of a receive secure channel.
+func UpdateMacsecRxSc(ctx *Context, ifindex uint32, sci uint64, active bool) error {
+
+	return macsecCommand(
+		ctx, MACSEC_CMD_UPD_RXSC, ifindex, &sci, &active, nil, false)
+
+}
+
+// DelMacsecRxSc removes a receive secure channel along with its associations.
+func DelMacsecRxSc(ctx *Context, ifindex uint32, sci uint64) error {
+
+	return macsecCommand(ctx, MACSEC_CMD_DEL_RXSC, ifindex, &sci, nil, nil, false)
+
+}
+
+// AddMacsecRxSa adds a receive secure association to the receive secure
+// channel with the provided sci.
+func AddMacsecRxSa(ctx *Context, ifindex uint32, sci uint64, sa *MacsecSa) error {
+
+	return macsecCommand(ctx, MACSEC_CMD_ADD_RXSA, ifindex, &sci, nil, sa, true)
+
+}
+
+// UpdateMacsecRxSa changes the activity and packet number of a receive secure
+// association.
+func UpdateMacsecRxSa(
+	ctx *Context, ifindex uint32, sci uint64, sa *MacsecSa) error {
+
+	return macsecCommand(ctx, MACSEC_CMD_UPD_RXSA, ifindex, &sci, nil, sa, false)
+
+}
+
+// DelMacsecRxSa removes the receive secure association with the provided
+// association number from the receive secure channel with the provided sci.
+func DelMacsecRxSa(ctx *Context, ifindex uint32, sci uint64, an uint8) error {
+
+	return macsecCommand(
+		ctx, MACSEC_CMD_DEL_RXSA, ifindex, &sci, nil, &MacsecSa{An: an}, false)
+
+}
+
+// macsecCommand sends a secure channel or association command to the macsec
+// generic netlink family. Keys are only sent when adding associations, the
+// kernel rejects them otherwise.
+func macsecCommand(
+	ctx *Context, cmd uint8, ifindex uint32, sci *uint64, active *bool,
+	sa *MacsecSa, keys bool,
+) error {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(MACSEC_ATTR_IFINDEX, ifindex)
+
+	if sci != nil {
+		ae.Do(MACSEC_ATTR_RXSC_CONFIG, func() ([]byte, error) {
+			ae1 := netlink.NewAttributeEncoder()
+			ae1.Bytes(MACSEC_RXSC_ATTR_SCI, sciBytes(*sci))
+			if active != nil {
+				ae1.Uint8(MACSEC_RXSC_ATTR_ACTIVE, boolByte(*active))
+			}
+			return ae1.Encode()
+		})
+	}
+
+	if sa != nil {
+		ae.Do(MACSEC_ATTR_SA_CONFIG, func() ([]byte, error) {
+			return sa.marshal(keys)
+		})
+	}
+
+	attrs, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode macsec command")
+		return err
+	}
+
+	_, err = ctx.GenlExecute(macsecFamilyName, cmd,
+		netlink.Request|netlink.Acknowledge, attrs)
+	return err
+
+}
+
+func (sa *MacsecSa) marshal(keys bool) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint8(MACSEC_SA_ATTR_AN, sa.An)
+
+	if sa.Active != nil {
+		ae.Uint8(MACSEC_SA_ATTR_ACTIVE, boolByte(*sa.Active))
+	}
+
+	// the kernel expects the packet number width of the cipher suite
+	if sa.Pn != 0 {
+		if sa.Xpn {
+			ae.Uint64(MACSEC_SA_ATTR_PN, sa.Pn)
+		} else {
+			ae.Uint32(MACSEC_SA_ATTR_PN, uint32(sa.Pn))
+		}
+	}
+
+	if keys {
+		ae.Bytes(MACSEC_SA_ATTR_KEY, sa.Key)
+		ae.Bytes(MACSEC_SA_ATTR_KEYID, sa.KeyId[:])
+		if sa.Xpn {
+			ae.Uint32(MACSEC_SA_ATTR_SSCI, htonl(sa.Ssci))
+			ae.Bytes(MACSEC_SA_ATTR_SALT, sa.Salt)
+		}
+	}
+
+	return ae.Encode()
+
+}
+
+// sciBytes returns the wire format of a secure channel identifier, the link
+// address followed by the port. The kernel keeps identifiers as a __be64
+// (sci_t), so they travel in network byte order regardless of the host.
+func sciBytes(sci uint64) []byte {
+
+	buf := make([]byte, sciLen)
+	binary.BigEndian.PutUint64(buf, sci)
+	return buf
+
+}
+
+// sciFromBytes reads a secure channel identifier from its network byte order
+// wire format.
+func sciFromBytes(buf []byte) uint64 {
+
+	return binary.BigEndian.Uint64(buf[:sciLen])
+
+}
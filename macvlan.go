@@ -2,6 +2,7 @@ package rtnl
 
 import (
 	"fmt"
+	"net"
 
 	"github.com/mdlayher/netlink"
 	"golang.org/x/sys/unix"
@@ -34,9 +35,19 @@ const (
 	MACVLAN_MACADDR_SET
 )
 
+// macvlan flags
+const (
+	MACVLAN_FLAG_NOPROMISC uint16 = 1 << iota
+	MACVLAN_FLAG_NODST
+)
+
 type Macvlan struct {
-	Mode MacvlanMode
-	Link uint32
+	Mode  MacvlanMode
+	Link  uint32
+	Flags uint16
+
+	// the source addresses allowed in source mode
+	SourceMacs []net.HardwareAddr
 }
 
 func (m *Macvlan) Marshal(ctx *Context) ([]byte, error) {
@@ -51,6 +62,13 @@ func (m *Macvlan) Marshal(ctx *Context) ([]byte, error) {
 
 			ae2 := netlink.NewAttributeEncoder()
 			ae2.Uint32(IFLA_MACVLAN_MODE, uint32(m.Mode))
+			if m.Flags != 0 {
+				ae2.Uint16(IFLA_MACVLAN_FLAGS, m.Flags)
+			}
+			if len(m.SourceMacs) > 0 {
+				ae2.Uint32(IFLA_MACVLAN_MACADDR_MODE, MACVLAN_MACADDR_SET)
+				ae2.Do(IFLA_MACVLAN_MACADDR_DATA, macvlanMacs(m.SourceMacs))
+			}
 
 			return ae2.Encode()
 
@@ -77,6 +95,20 @@ func (m *Macvlan) Unmarshal(ctx *Context, buf []byte) error {
 		case IFLA_MACVLAN_MODE:
 			m.Mode = MacvlanMode(ad.Uint32())
 
+		case IFLA_MACVLAN_FLAGS:
+			m.Flags = ad.Uint16()
+
+		case IFLA_MACVLAN_MACADDR_DATA:
+			nad, err := netlink.NewAttributeDecoder(ad.Bytes())
+			if err != nil {
+				return err
+			}
+			for nad.Next() {
+				if nad.Type() == IFLA_MACVLAN_MACADDR {
+					m.SourceMacs = append(m.SourceMacs, net.HardwareAddr(nad.Bytes()))
+				}
+			}
+
 		}
 	}
 
@@ -90,6 +122,134 @@ func (m *Macvlan) Resolve(ctx *Context) error {
 
 }
 
+// AddMacvlanSource allows the provided source address on this source mode
+// macvlan link.
+func (l *Link) AddMacvlanSource(ctx *Context, mac net.HardwareAddr) error {
+
+	return l.modifyMacvlanSources(ctx, MACVLAN_MACADDR_ADD, mac)
+
+}
+
+// DelMacvlanSource disallows the provided source address on this source mode
+// macvlan link.
+func (l *Link) DelMacvlanSource(ctx *Context, mac net.HardwareAddr) error {
+
+	return l.modifyMacvlanSources(ctx, MACVLAN_MACADDR_DEL, mac)
+
+}
+
+// FlushMacvlanSources removes all allowed source addresses from this source
+// mode macvlan link.
+func (l *Link) FlushMacvlanSources(ctx *Context) error {
+
+	return l.modifyMacvlanSources(ctx, MACVLAN_MACADDR_FLUSH)
+
+}
+
+// SetMacvlanSources replaces the allowed source addresses of this source mode
+// macvlan link.
+func (l *Link) SetMacvlanSources(ctx *Context, macs []net.HardwareAddr) error {
+
+	return l.modifyMacvlanSources(ctx, MACVLAN_MACADDR_SET, macs...)
+
+}
+
+// SetMacvlanFlags replaces the flags of this macvlan link. The flags are always
+// sent, so flags that are not provided are cleared.
+func (l *Link) SetMacvlanFlags(ctx *Context, flags uint16) error {
+
+	return l.macvlanChangelink(ctx, func(ae *netlink.AttributeEncoder) error {
+		ae.Uint16(IFLA_MACVLAN_FLAGS, flags)
+		return nil
+	})
+
+}
+
+// modifyMacvlanSources changes the source address list of this macvlan link.
+func (l *Link) modifyMacvlanSources(
+	ctx *Context, mode uint32, macs ...net.HardwareAddr) error {
+
+	return l.macvlanChangelink(ctx, func(ae *netlink.AttributeEncoder) error {
+
+		ae.Uint32(IFLA_MACVLAN_MACADDR_MODE, mode)
+
+		switch mode {
+		case MACVLAN_MACADDR_ADD, MACVLAN_MACADDR_DEL:
+			if len(macs) != 1 {
+				return fmt.Errorf("expected a single source address")
+			}
+			ae.Bytes(IFLA_MACVLAN_MACADDR, macs[0])
+		case MACVLAN_MACADDR_SET:
+			ae.Do(IFLA_MACVLAN_MACADDR_DATA, macvlanMacs(macs))
+		}
+
+		return nil
+
+	})
+
+}
+
+// macvlanChangelink applies the macvlan attributes encoded by f through a
+// macvlan changelink, which the kernel only performs on RTM_NEWLINK.
+func (l *Link) macvlanChangelink(
+	ctx *Context, f func(*netlink.AttributeEncoder) error) error {
+
+	if l.Msg.Index == 0 {
+		err := l.Read(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte("macvlan"))
+		ae1.Do(IFLA_INFO_DATA, func() ([]byte, error) {
+
+			ae2 := netlink.NewAttributeEncoder()
+			err := f(ae2)
+			if err != nil {
+				return nil, err
+			}
+
+			return ae2.Encode()
+
+		})
+
+		return ae1.Encode()
+
+	})
+	attrs, err := ae.Encode()
+	if err != nil {
+		return err
+	}
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.RTM_NEWLINK),
+			Flags: netlink.Request | netlink.Acknowledge,
+		},
+		Data: append(IfInfomsgBytes(l.Msg), attrs...),
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
+
+}
+
+func macvlanMacs(macs []net.HardwareAddr) func() ([]byte, error) {
+
+	return func() ([]byte, error) {
+		ae := netlink.NewAttributeEncoder()
+		for _, mac := range macs {
+			ae.Bytes(IFLA_MACVLAN_MACADDR, mac)
+		}
+		return ae.Encode()
+	}
+
+}
+
 func ParseMacvlanMode(mode string) (MacvlanMode, error) {
 
 	switch mode {
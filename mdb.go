@@ -0,0 +1,579 @@
+package rtnl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	brPortMsgLen  = 8
+	brMdbEntryLen = 28
+)
+
+// mdb attribute types
+const (
+	MDBA_UNSPEC uint16 = iota
+	MDBA_MDB
+	MDBA_ROUTER
+	MDBA_SET_ENTRY
+	MDBA_SET_ENTRY_ATTRS
+)
+
+const (
+	MDBA_MDB_UNSPEC uint16 = iota
+	MDBA_MDB_ENTRY
+)
+
+const (
+	MDBA_MDB_ENTRY_UNSPEC uint16 = iota
+	MDBA_MDB_ENTRY_INFO
+)
+
+// mdb entry info attribute types
+const (
+	MDBA_MDB_EATTR_UNSPEC uint16 = iota
+	MDBA_MDB_EATTR_TIMER
+	MDBA_MDB_EATTR_SRC_LIST
+	MDBA_MDB_EATTR_GROUP_MODE
+	MDBA_MDB_EATTR_SOURCE
+	MDBA_MDB_EATTR_RTPROT
+)
+
+const (
+	MDBA_MDB_SRCLIST_UNSPEC uint16 = iota
+	MDBA_MDB_SRCLIST_ENTRY
+)
+
+const (
+	MDBA_MDB_SRCATTR_UNSPEC uint16 = iota
+	MDBA_MDB_SRCATTR_ADDRESS
+	MDBA_MDB_SRCATTR_TIMER
+)
+
+const (
+	MDBA_ROUTER_UNSPEC uint16 = iota
+	MDBA_ROUTER_PORT
+)
+
+// mdb router port attribute types
+const (
+	MDBA_ROUTER_PATTR_UNSPEC uint16 = iota
+	MDBA_ROUTER_PATTR_TIMER
+	MDBA_ROUTER_PATTR_TYPE
+	MDBA_ROUTER_PATTR_INET_TIMER
+	MDBA_ROUTER_PATTR_INET6_TIMER
+	MDBA_ROUTER_PATTR_VID
+)
+
+// mdb set entry attribute types
+const (
+	MDBE_ATTR_UNSPEC uint16 = iota
+	MDBE_ATTR_SOURCE
+	MDBE_ATTR_SRC_LIST
+	MDBE_ATTR_GROUP_MODE
+	MDBE_ATTR_RTPROT
+)
+
+const (
+	MDBE_SRC_LIST_UNSPEC uint16 = iota
+	MDBE_SRC_LIST_ENTRY
+)
+
+const (
+	MDBE_SRCATTR_UNSPEC uint16 = iota
+	MDBE_SRCATTR_ADDRESS
+)
+
+// mdb entry states
+const (
+	MDB_TEMPORARY uint8 = iota
+	MDB_PERMANENT
+)
+
+// mdb entry flags
+const (
+	MDB_FLAGS_OFFLOAD    = 1 << 0
+	MDB_FLAGS_FAST_LEAVE = 1 << 1
+	MDB_FLAGS_STAR_EXCL  = 1 << 2
+	MDB_FLAGS_BLOCKED    = 1 << 3
+)
+
+// igmpv3/mldv2 group filter modes
+const (
+	MCAST_EXCLUDE uint8 = iota
+	MCAST_INCLUDE
+)
+
+// Data Structures ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// MdbEntry is a multicast group membership of a bridge port in the bridge
+// multicast database.
+type MdbEntry struct {
+	Bridge    uint32 // bridge interface index
+	Port      uint32 // port interface index
+	Group     net.IP
+	Source    net.IP // source of an (S, G) entry
+	Vid       uint16
+	Permanent bool
+
+	// igmpv3/mldv2 properties
+	GroupMode uint8
+	Sources   []MdbSource
+
+	// read only state
+	Flags uint8
+	Timer uint32 // hundredths of a second
+}
+
+// MdbSource is a source address of an igmpv3/mldv2 group membership.
+type MdbSource struct {
+	Address net.IP
+	Timer   uint32
+}
+
+// MdbRouterPort is a bridge port behind which a multicast router lives.
+type MdbRouterPort struct {
+	Bridge uint32
+	Port   uint32
+	Timer  uint32
+	Type   uint8
+}
+
+// Methods ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// Marshal turns an mdb entry into a binary rtnetlink message and a set of
+// attributes.
+func (e *MdbEntry) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(MDBA_SET_ENTRY, e.entryBytes)
+
+	if e.Source != nil || e.Sources != nil || e.GroupMode != MCAST_EXCLUDE {
+
+		ae.Do(MDBA_SET_ENTRY_ATTRS|unix.NLA_F_NESTED, func() ([]byte, error) {
+
+			ae1 := netlink.NewAttributeEncoder()
+
+			if e.Source != nil {
+				ae1.Bytes(MDBE_ATTR_SOURCE, ipBytes(e.Source))
+			}
+
+			if e.Sources != nil {
+				ae1.Do(MDBE_ATTR_SRC_LIST|unix.NLA_F_NESTED, func() ([]byte, error) {
+
+					ae2 := netlink.NewAttributeEncoder()
+					for _, s := range e.Sources {
+						src := s.Address
+						ae2.Do(MDBE_SRC_LIST_ENTRY|unix.NLA_F_NESTED, func() ([]byte, error) {
+							ae3 := netlink.NewAttributeEncoder()
+							ae3.Bytes(MDBE_SRCATTR_ADDRESS, ipBytes(src))
+							return ae3.Encode()
+						})
+					}
+					return ae2.Encode()
+
+				})
+				ae1.Uint8(MDBE_ATTR_GROUP_MODE, e.GroupMode)
+			} else if e.GroupMode != MCAST_EXCLUDE {
+				ae1.Uint8(MDBE_ATTR_GROUP_MODE, e.GroupMode)
+			}
+
+			return ae1.Encode()
+
+		})
+
+	}
+
+	attrs, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode mdb attributes")
+		return nil, err
+	}
+
+	return append(brPortMsgBytes(e.Bridge), attrs...), nil
+
+}
+
+// entryBytes builds a struct br_mdb_entry
+func (e *MdbEntry) entryBytes() ([]byte, error) {
+
+	buf := make([]byte, brMdbEntryLen)
+	binary.LittleEndian.PutUint32(buf[0:4], e.Port)
+	if e.Permanent {
+		buf[4] = MDB_PERMANENT
+	}
+	buf[5] = e.Flags
+	binary.LittleEndian.PutUint16(buf[6:8], e.Vid)
+
+	if e.Group == nil {
+		return nil, fmt.Errorf("mdb entry requires a group")
+	}
+
+	if g := e.Group.To4(); g != nil {
+		copy(buf[8:12], g)
+		binary.BigEndian.PutUint16(buf[24:26], unix.ETH_P_IP)
+	} else {
+		copy(buf[8:24], e.Group.To16())
+		binary.BigEndian.PutUint16(buf[24:26], unix.ETH_P_IPV6)
+	}
+
+	return buf, nil
+
+}
+
+// Unmarshal reads an mdb entry from the payload of an MDBA_MDB_ENTRY_INFO
+// attribute, a struct br_mdb_entry followed by entry attributes.
+func (e *MdbEntry) Unmarshal(ctx *Context, buf []byte) error {
+
+	if len(buf) < brMdbEntryLen {
+		return fmt.Errorf("short mdb entry")
+	}
+
+	e.Port = binary.LittleEndian.Uint32(buf[0:4])
+	e.Permanent = buf[4] == MDB_PERMANENT
+	e.Flags = buf[5]
+	e.Vid = binary.LittleEndian.Uint16(buf[6:8])
+
+	switch binary.BigEndian.Uint16(buf[24:26]) {
+	case unix.ETH_P_IP:
+		e.Group = net.IP(append([]byte{}, buf[8:12]...))
+	case unix.ETH_P_IPV6:
+		e.Group = net.IP(append([]byte{}, buf[8:24]...))
+	}
+
+	if len(buf) == brMdbEntryLen {
+		return nil
+	}
+
+	ad, err := netlink.NewAttributeDecoder(buf[brMdbEntryLen:])
+	if err != nil {
+		log.WithError(err).Error("failed to create mdb entry decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+
+		case MDBA_MDB_EATTR_TIMER:
+			e.Timer = ad.Uint32()
+
+		case MDBA_MDB_EATTR_GROUP_MODE:
+			e.GroupMode = ad.Uint8()
+
+		case MDBA_MDB_EATTR_SOURCE:
+			e.Source = net.IP(ad.Bytes())
+
+		case MDBA_MDB_EATTR_SRC_LIST:
+			ad.Do(func(b []byte) error {
+				sources, err := unmarshalMdbSources(b)
+				e.Sources = sources
+				return err
+			})
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+func unmarshalMdbSources(buf []byte) ([]MdbSource, error) {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []MdbSource
+	for ad.Next() {
+		if ad.Type()&nlaTypeMask != MDBA_MDB_SRCLIST_ENTRY {
+			continue
+		}
+
+		var src MdbSource
+		ad.Do(func(b []byte) error {
+
+			sad, err := netlink.NewAttributeDecoder(b)
+			if err != nil {
+				return err
+			}
+			for sad.Next() {
+				switch sad.Type() & nlaTypeMask {
+				case MDBA_MDB_SRCATTR_ADDRESS:
+					src.Address = net.IP(sad.Bytes())
+				case MDBA_MDB_SRCATTR_TIMER:
+					src.Timer = sad.Uint32()
+				}
+			}
+			return sad.Err()
+
+		})
+		result = append(result, src)
+	}
+
+	return result, ad.Err()
+
+}
+
+// ReadMdb reads the multicast database entries that satisfy the provided spec.
+// The bridge, port, group and vid of the spec are used as filters when set.
+func ReadMdb(ctx *Context, spec *MdbEntry) ([]*MdbEntry, error) {
+
+	if spec == nil {
+		spec = &MdbEntry{}
+	}
+
+	var result []*MdbEntry
+
+	err := dumpMdb(ctx, spec.Bridge, func(bridge uint32, typ uint16, buf []byte) error {
+
+		if typ != MDBA_MDB {
+			return nil
+		}
+
+		entries, err := unmarshalMdb(ctx, bridge, buf)
+		for _, e := range entries {
+			if e.Satisfies(spec) {
+				result = append(result, e)
+			}
+		}
+		return err
+
+	})
+
+	return result, err
+
+}
+
+// unmarshalMdb reads the entries of an MDBA_MDB attribute.
+func unmarshalMdb(ctx *Context, bridge uint32, buf []byte) ([]*MdbEntry, error) {
+
+	var result []*MdbEntry
+
+	err := forEachNested(buf, MDBA_MDB_ENTRY, func(ebuf []byte) error {
+		return forEachNested(ebuf, MDBA_MDB_ENTRY_INFO, func(ibuf []byte) error {
+
+			e := &MdbEntry{Bridge: bridge}
+			err := e.Unmarshal(ctx, ibuf)
+			if err != nil {
+				return err
+			}
+
+			result = append(result, e)
+			return nil
+
+		})
+	})
+
+	return result, err
+
+}
+
+// ReadMdbRouters reads the multicast router ports of the bridge with the
+// provided index, or of all bridges if the index is zero.
+func ReadMdbRouters(ctx *Context, bridge uint32) ([]*MdbRouterPort, error) {
+
+	var result []*MdbRouterPort
+
+	err := dumpMdb(ctx, bridge, func(br uint32, typ uint16, buf []byte) error {
+
+		if typ != MDBA_ROUTER {
+			return nil
+		}
+
+		ports, err := unmarshalMdbRouters(br, buf)
+		for _, r := range ports {
+			if bridge == 0 || bridge == br {
+				result = append(result, r)
+			}
+		}
+		return err
+
+	})
+
+	return result, err
+
+}
+
+// unmarshalMdbRouters reads the router ports of an MDBA_ROUTER attribute.
+func unmarshalMdbRouters(bridge uint32, buf []byte) ([]*MdbRouterPort, error) {
+
+	var result []*MdbRouterPort
+
+	err := forEachNested(buf, MDBA_ROUTER_PORT, func(pbuf []byte) error {
+
+		if len(pbuf) < 4 {
+			return fmt.Errorf("short mdb router port")
+		}
+
+		r := &MdbRouterPort{
+			Bridge: bridge,
+			Port:   binary.LittleEndian.Uint32(pbuf[0:4]),
+		}
+
+		// newer kernels follow the port index with port attributes
+		if len(pbuf) > 4 {
+			ad, err := netlink.NewAttributeDecoder(pbuf[4:])
+			if err != nil {
+				return err
+			}
+			for ad.Next() {
+				switch ad.Type() & nlaTypeMask {
+				case MDBA_ROUTER_PATTR_TIMER:
+					r.Timer = ad.Uint32()
+				case MDBA_ROUTER_PATTR_TYPE:
+					r.Type = ad.Uint8()
+				}
+			}
+		}
+
+		result = append(result, r)
+		return nil
+
+	})
+
+	return result, err
+
+}
+
+// AddMdb adds the specified entry to the multicast database.
+func AddMdb(ctx *Context, e *MdbEntry) error {
+
+	return e.Modify(ctx, unix.RTM_NEWMDB)
+
+}
+
+// DelMdb removes the specified entry from the multicast database.
+func DelMdb(ctx *Context, e *MdbEntry) error {
+
+	return e.Modify(ctx, unix.RTM_DELMDB)
+
+}
+
+// Modify changes the mdb entry according to the supplied operation. Supported
+// operations include RTM_NEWMDB and RTM_DELMDB.
+func (e *MdbEntry) Modify(ctx *Context, op uint16) error {
+
+	data, err := e.Marshal(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to marshal mdb entry")
+		return err
+	}
+
+	flags := netlink.Request |
+		netlink.Acknowledge
+
+	if op == unix.RTM_NEWMDB {
+		flags |= netlink.Create | netlink.Excl
+	}
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(op),
+			Flags: flags,
+		},
+		Data: data,
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
+
+}
+
+// Satisfies returns true if this mdb entry satisfies the provided spec.
+func (e *MdbEntry) Satisfies(spec *MdbEntry) bool {
+
+	if spec == nil {
+		return true
+	}
+
+	if spec.Bridge != 0 && spec.Bridge != e.Bridge {
+		return false
+	}
+	if spec.Port != 0 && spec.Port != e.Port {
+		return false
+	}
+	if spec.Vid != 0 && spec.Vid != e.Vid {
+		return false
+	}
+	if spec.Group != nil && !spec.Group.Equal(e.Group) {
+		return false
+	}
+
+	return true
+
+}
+
+// helpers ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// brPortMsgBytes builds a struct br_port_msg
+func brPortMsgBytes(ifindex uint32) []byte {
+
+	msg := make([]byte, brPortMsgLen)
+	msg[0] = unix.AF_BRIDGE
+	binary.LittleEndian.PutUint32(msg[4:8], ifindex)
+	return msg
+
+}
+
+// dumpMdb dumps the multicast database and hands each top level attribute of
+// each response to f along with the index of the bridge it belongs to.
+func dumpMdb(ctx *Context, bridge uint32, f func(uint32, uint16, []byte) error) error {
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  unix.RTM_GETMDB,
+			Flags: netlink.Request | netlink.Dump,
+		},
+		Data: brPortMsgBytes(bridge),
+	}
+
+	return withNsNetlink(ctx.Fd(), func(conn *netlink.Conn) error {
+
+		resp, err := conn.Execute(m)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resp {
+			err := unmarshalBrPortMsg(r.Data, f)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	})
+
+}
+
+// unmarshalBrPortMsg hands each top level attribute of an mdb message to f
+// along with the index of the bridge it belongs to.
+func unmarshalBrPortMsg(buf []byte, f func(uint32, uint16, []byte) error) error {
+
+	if len(buf) < brPortMsgLen {
+		return nil
+	}
+	br := binary.LittleEndian.Uint32(buf[4:8])
+
+	ad, err := netlink.NewAttributeDecoder(buf[brPortMsgLen:])
+	if err != nil {
+		log.WithError(err).Error("error creating mdb decoder")
+		return err
+	}
+
+	for ad.Next() {
+		err := f(br, ad.Type()&nlaTypeMask, ad.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	return ad.Err()
+
+}
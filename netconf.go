@@ -0,0 +1,178 @@
+package rtnl
+
+import (
+	"fmt"
+
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// netconf attribute types
+const (
+	NETCONFA_UNSPEC uint16 = iota
+	NETCONFA_IFINDEX
+	NETCONFA_FORWARDING
+	NETCONFA_RP_FILTER
+	NETCONFA_MC_FORWARDING
+	NETCONFA_PROXY_NEIGH
+	NETCONFA_IGNORE_ROUTES_WITH_LINKDOWN
+	NETCONFA_INPUT
+	NETCONFA_BC_FORWARDING
+)
+
+// pseudo interface indices for the all and default configurations
+const (
+	NETCONFA_IFINDEX_ALL     int32 = -1
+	NETCONFA_IFINDEX_DEFAULT int32 = -2
+)
+
+const netconfMsgLen = 4
+
+// Netconf holds the forwarding related configuration of an address family on
+// an interface, or on all interfaces, or for new interfaces. Settings an
+// address family does not report are nil.
+type Netconf struct {
+	Family  uint8
+	Ifindex int32
+
+	Forwarding               *int32
+	RpFilter                 *int32
+	McForwarding             *int32
+	ProxyNeigh               *int32
+	IgnoreRoutesWithLinkdown *int32
+	Input                    *int32
+	BcForwarding             *int32
+}
+
+// ReadNetconf reads the configuration of the provided address family for the
+// interface with the provided index, which may be NETCONFA_IFINDEX_ALL or
+// NETCONFA_IFINDEX_DEFAULT.
+func ReadNetconf(ctx *Context, family uint8, ifindex int32) (*Netconf, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Bytes(NETCONFA_IFINDEX, int32Bytes(ifindex))
+	attrs, err := ae.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := readNetconf(ctx, family, netlink.Request, attrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("not found")
+	}
+
+	return result[0], nil
+
+}
+
+// ReadNetconfs reads the configuration of the provided address family for all
+// interfaces, including the all and default configurations.
+func ReadNetconfs(ctx *Context, family uint8) ([]*Netconf, error) {
+
+	return readNetconf(ctx, family, netlink.Request|netlink.Dump, nil)
+
+}
+
+func readNetconf(
+	ctx *Context, family uint8, flags netlink.HeaderFlags, attrs []byte,
+) ([]*Netconf, error) {
+
+	msg := make([]byte, netconfMsgLen)
+	msg[0] = family
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  unix.RTM_GETNETCONF,
+			Flags: flags,
+		},
+		Data: append(msg, attrs...),
+	}
+
+	var result []*Netconf
+	err := withNsNetlink(ctx.Fd(), func(conn *netlink.Conn) error {
+
+		resp, err := conn.Execute(m)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resp {
+
+			if r.Header.Type != unix.RTM_NEWNETCONF {
+				continue
+			}
+
+			nc := &Netconf{}
+			err := nc.Unmarshal(ctx, r.Data)
+			if err != nil {
+				return err
+			}
+			result = append(result, nc)
+
+		}
+
+		return nil
+
+	})
+
+	return result, err
+
+}
+
+// Unmarshal reads a netconf from a binary rtnetlink message.
+func (n *Netconf) Unmarshal(ctx *Context, buf []byte) error {
+
+	if len(buf) < netconfMsgLen {
+		return fmt.Errorf("short netconf message")
+	}
+	n.Family = buf[0]
+
+	ad, err := netlink.NewAttributeDecoder(buf[netconfMsgLen:])
+	if err != nil {
+		log.WithError(err).Error("failed to create netconf attribute decoder")
+		return err
+	}
+
+	value := func() *int32 {
+		v := nlenc.Int32(ad.Bytes())
+		return &v
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case NETCONFA_IFINDEX:
+			n.Ifindex = nlenc.Int32(ad.Bytes())
+
+		case NETCONFA_FORWARDING:
+			n.Forwarding = value()
+
+		case NETCONFA_RP_FILTER:
+			n.RpFilter = value()
+
+		case NETCONFA_MC_FORWARDING:
+			n.McForwarding = value()
+
+		case NETCONFA_PROXY_NEIGH:
+			n.ProxyNeigh = value()
+
+		case NETCONFA_IGNORE_ROUTES_WITH_LINKDOWN:
+			n.IgnoreRoutesWithLinkdown = value()
+
+		case NETCONFA_INPUT:
+			n.Input = value()
+
+		case NETCONFA_BC_FORWARDING:
+			n.BcForwarding = value()
+
+		}
+	}
+
+	return ad.Err()
+
+}
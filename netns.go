@@ -0,0 +1,130 @@
+package rtnl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	rtGenMsgLen = 4
+
+	// NETNSA_NSID_NOT_ASSIGNED is returned for namespaces that have no id in
+	// the namespace of the asking context
+	NETNSA_NSID_NOT_ASSIGNED int32 = -1
+
+	// the name used for the namespace of init
+	DefaultNamespace = "default"
+)
+
+// netns attribute types
+const (
+	NETNSA_NONE uint16 = iota
+	NETNSA_NSID
+	NETNSA_PID
+	NETNSA_FD
+)
+
+// GetNsid returns the id the namespace of the provided context uses to refer to
+// the namespace behind the provided file descriptor.
+func GetNsid(ctx *Context, fd int) (int32, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(NETNSA_FD, uint32(fd))
+	attrs, err := ae.Encode()
+	if err != nil {
+		return 0, err
+	}
+
+	msg := make([]byte, rtGenMsgLen)
+	msg[0] = unix.AF_UNSPEC
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  unix.RTM_GETNSID,
+			Flags: netlink.Request,
+		},
+		Data: append(msg, attrs...),
+	}
+
+	nsid := NETNSA_NSID_NOT_ASSIGNED
+	err = withNsNetlink(ctx.Fd(), func(conn *netlink.Conn) error {
+
+		resp, err := conn.Execute(m)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resp {
+
+			if r.Header.Type != unix.RTM_NEWNSID || len(r.Data) < rtGenMsgLen {
+				continue
+			}
+
+			ad, err := netlink.NewAttributeDecoder(r.Data[rtGenMsgLen:])
+			if err != nil {
+				log.WithError(err).Error("error creating nsid decoder")
+				return err
+			}
+			for ad.Next() {
+				if ad.Type() == NETNSA_NSID {
+					nsid = nlenc.Int32(ad.Bytes())
+				}
+			}
+
+		}
+
+		return nil
+
+	})
+
+	return nsid, err
+
+}
+
+// FindNamespace returns a context for, and the name of, the namespace the
+// provided context refers to by nsid. Named namespaces under /var/run/netns
+// are searched first, then the namespace of init which is called "default".
+// The caller is responsible for closing the returned context.
+func FindNamespace(ctx *Context, nsid int32) (*Context, string, error) {
+
+	var names []string
+	files, err := ioutil.ReadDir("/var/run/netns")
+	if err == nil {
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+	}
+	names = append(names, DefaultNamespace)
+
+	for _, name := range names {
+
+		var nctx *Context
+		if name == DefaultNamespace {
+			nctx, err = OpenDefaultContext()
+		} else {
+			nctx, err = OpenContext(name)
+		}
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.WithError(err).WithField("ns", name).Warn("failed to open netns")
+			}
+			continue
+		}
+
+		id, err := GetNsid(ctx, nctx.Fd())
+		if err == nil && id == nsid {
+			return nctx, name, nil
+		}
+		nctx.Close()
+
+	}
+
+	return nil, "", fmt.Errorf("namespace with nsid %d not found", nsid)
+
+}
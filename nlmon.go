@@ -0,0 +1,40 @@
+package rtnl
+
+import (
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Nlmon encapsulates netlink monitor links, which capture the netlink
+// traffic of their namespace
+type Nlmon struct {
+}
+
+func (t *Nlmon) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte("nlmon"))
+
+		return ae1.Encode()
+
+	})
+
+	return ae.Encode()
+
+}
+
+// Unmarshal is a no-op, nlmon links carry no kind specific attributes.
+func (t *Nlmon) Unmarshal(ctx *Context, buf []byte) error {
+
+	return nil
+
+}
+
+func (t *Nlmon) Resolve(ctx *Context) error {
+
+	return nil
+
+}
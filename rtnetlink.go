@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sync"
 
 	"github.com/mdlayher/netlink"
 	log "github.com/sirupsen/logrus"
@@ -16,6 +17,10 @@ var Version = "undefined"
 type Context struct {
 	f      *os.File
 	Target *Context
+
+	// generic netlink families resolved in this context's namespace
+	genlMu sync.Mutex
+	genl   map[string]*GenlFamily
 }
 
 func (c *Context) Fd() int {
@@ -98,11 +103,16 @@ func withNetlink(f func(*netlink.Conn) error) error {
 
 func withNsNetlink(ns int, f func(*netlink.Conn) error) error {
 
+	return withNsNetlinkProto(ns, unix.NETLINK_ROUTE, f)
+
+}
+
+func withNsNetlinkProto(ns, proto int, f func(*netlink.Conn) error) error {
+
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 
-	conn, err := netlink.Dial(
-		unix.NETLINK_ROUTE, &netlink.Config{NetNS: ns})
+	conn, err := netlink.Dial(proto, &netlink.Config{NetNS: ns})
 	if err != nil {
 		log.WithError(err).Error("failed to dial netlink")
 		return err
@@ -114,7 +124,11 @@ func withNsNetlink(ns int, f func(*netlink.Conn) error) error {
 }
 
 func netlinkUpdate(ctx *Context, messages []netlink.Message) error {
-	return withNsNetlink(ctx.Fd(), func(c *netlink.Conn) error {
+	return netlinkNsUpdate(ctx.Fd(), messages)
+}
+
+func netlinkNsUpdate(ns int, messages []netlink.Message) error {
+	return withNsNetlink(ns, func(c *netlink.Conn) error {
 
 		for _, m := range messages {
 
@@ -134,7 +148,7 @@ func netlinkUpdate(ctx *Context, messages []netlink.Message) error {
 						log.WithFields(log.Fields{
 							"code": code,
 						}).Warn("netlink update failed")
-						return fmt.Errorf(string(r.Data))
+						return fmt.Errorf("%s", string(r.Data))
 					}
 
 				}
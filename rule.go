@@ -60,6 +60,9 @@ type Rule struct {
 	Iif      string
 	Fwmark   uint32
 	Table    uint32
+
+	// look up the table of the l3 master device (vrf) of the packet
+	L3mdev bool
 }
 
 type Fib struct {
@@ -134,6 +137,10 @@ func (r *Rule) Marshal(ctx *Context) ([]byte, error) {
 		ae.Uint32(FRA_TABLE, r.Table)
 	}
 
+	if r.L3mdev {
+		ae.Uint8(FRA_L3MDEV, 1)
+	}
+
 	attrs, err := ae.Encode()
 	if err != nil {
 		return nil, err
@@ -188,6 +195,9 @@ func (r *Rule) Unmarshal(ctx *Context, buf []byte) error {
 		case FRA_FWMARK:
 			r.Fwmark = ad.Uint32()
 
+		case FRA_L3MDEV:
+			r.L3mdev = ad.Uint8() != 0
+
 		}
 	}
 
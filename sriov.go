@@ -0,0 +1,387 @@
+package rtnl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// virtual function list attribute types
+const (
+	IFLA_VF_INFO_UNSPEC uint16 = iota
+	IFLA_VF_INFO
+)
+
+// virtual function attribute types
+const (
+	IFLA_VF_UNSPEC uint16 = iota
+	IFLA_VF_MAC
+	IFLA_VF_VLAN
+	IFLA_VF_TX_RATE
+	IFLA_VF_SPOOFCHK
+	IFLA_VF_LINK_STATE
+	IFLA_VF_RATE
+	IFLA_VF_RSS_QUERY_EN
+	IFLA_VF_STATS
+	IFLA_VF_TRUST
+	IFLA_VF_IB_NODE_GUID
+	IFLA_VF_IB_PORT_GUID
+	IFLA_VF_VLAN_LIST
+	IFLA_VF_BROADCAST
+)
+
+// virtual function statistics attribute types
+const (
+	IFLA_VF_STATS_RX_PACKETS uint16 = iota
+	IFLA_VF_STATS_TX_PACKETS
+	IFLA_VF_STATS_RX_BYTES
+	IFLA_VF_STATS_TX_BYTES
+	IFLA_VF_STATS_BROADCAST
+	IFLA_VF_STATS_MULTICAST
+	IFLA_VF_STATS_PAD
+	IFLA_VF_STATS_RX_DROPPED
+	IFLA_VF_STATS_TX_DROPPED
+)
+
+// VfLinkState aliases virtual function link states in a type safe way
+type VfLinkState uint32
+
+const (
+	IFLA_VF_LINK_STATE_AUTO VfLinkState = iota
+	IFLA_VF_LINK_STATE_ENABLE
+	IFLA_VF_LINK_STATE_DISABLE
+)
+
+// RTEXT_FILTER_VF asks the kernel to report virtual function information
+const RTEXT_FILTER_VF uint32 = 1
+
+const (
+	vfMacLen = 32
+
+	// drivers that do not support a setting report it as -1
+	vfSettingUnsupported = ^uint32(0)
+)
+
+// VfInfo holds the configuration and statistics of an SR-IOV virtual
+// function as reported by the physical function. Settings the driver does not
+// support are nil.
+type VfInfo struct {
+	Vf        uint32
+	Mac       net.HardwareAddr
+	Vlan      uint32
+	Qos       uint32
+	MinTxRate uint32 // in Mbps
+	MaxTxRate uint32 // in Mbps
+	SpoofChk  *bool
+	Trust     *bool
+	RssQuery  *bool
+	LinkState VfLinkState
+	Stats     *VfStats
+}
+
+// VfStats holds the traffic counters of a virtual function.
+type VfStats struct {
+	RxPackets uint64
+	TxPackets uint64
+	RxBytes   uint64
+	TxBytes   uint64
+	Broadcast uint64
+	Multicast uint64
+	RxDropped uint64
+	TxDropped uint64
+}
+
+// Unmarshal reads a virtual function from a binary set of attributes.
+func (v *VfInfo) Unmarshal(buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create vf attribute decoder")
+		return err
+	}
+
+	for ad.Next() {
+
+		// every vf attribute but the statistics starts with the vf number
+		typ := ad.Type() & nlaTypeMask
+		if typ == IFLA_VF_STATS {
+			v.Stats = &VfStats{}
+			err := v.Stats.Unmarshal(ad.Bytes())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		b := ad.Bytes()
+		if len(b) < 4 {
+			continue
+		}
+		v.Vf = binary.LittleEndian.Uint32(b[0:4])
+		u := func(i int) uint32 {
+			if len(b) < 4*(i+1) {
+				return 0
+			}
+			return binary.LittleEndian.Uint32(b[4*i:])
+		}
+
+		switch typ {
+
+		case IFLA_VF_MAC:
+			if len(b) >= 4+vfMacLen {
+				v.Mac = net.HardwareAddr(append([]byte{}, b[4:10]...))
+			}
+
+		case IFLA_VF_VLAN:
+			v.Vlan = u(1)
+			v.Qos = u(2)
+
+		case IFLA_VF_TX_RATE:
+			v.MaxTxRate = u(1)
+
+		case IFLA_VF_RATE:
+			v.MinTxRate = u(1)
+			v.MaxTxRate = u(2)
+
+		case IFLA_VF_SPOOFCHK:
+			v.SpoofChk = vfSetting(u(1))
+
+		case IFLA_VF_TRUST:
+			v.Trust = vfSetting(u(1))
+
+		case IFLA_VF_RSS_QUERY_EN:
+			v.RssQuery = vfSetting(u(1))
+
+		case IFLA_VF_LINK_STATE:
+			v.LinkState = VfLinkState(u(1))
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+// Unmarshal reads virtual function statistics from a binary set of
+// attributes.
+func (s *VfStats) Unmarshal(buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create vf stats decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_VF_STATS_RX_PACKETS:
+			s.RxPackets = ad.Uint64()
+
+		case IFLA_VF_STATS_TX_PACKETS:
+			s.TxPackets = ad.Uint64()
+
+		case IFLA_VF_STATS_RX_BYTES:
+			s.RxBytes = ad.Uint64()
+
+		case IFLA_VF_STATS_TX_BYTES:
+			s.TxBytes = ad.Uint64()
+
+		case IFLA_VF_STATS_BROADCAST:
+			s.Broadcast = ad.Uint64()
+
+		case IFLA_VF_STATS_MULTICAST:
+			s.Multicast = ad.Uint64()
+
+		case IFLA_VF_STATS_RX_DROPPED:
+			s.RxDropped = ad.Uint64()
+
+		case IFLA_VF_STATS_TX_DROPPED:
+			s.TxDropped = ad.Uint64()
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+// unmarshalVfInfoList reads the virtual functions of an IFLA_VFINFO_LIST.
+func unmarshalVfInfoList(buf []byte) ([]VfInfo, error) {
+
+	var result []VfInfo
+
+	err := forEachNested(buf, IFLA_VF_INFO, func(b []byte) error {
+		vf := VfInfo{}
+		err := vf.Unmarshal(b)
+		if err != nil {
+			return err
+		}
+		result = append(result, vf)
+		return nil
+	})
+
+	return result, err
+
+}
+
+func vfSetting(v uint32) *bool {
+
+	if v == vfSettingUnsupported {
+		return nil
+	}
+	b := v != 0
+	return &b
+
+}
+
+// Setters ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// SetVfMac sets the mac address of a virtual function of the link.
+func (l *Link) SetVfMac(ctx *Context, vf uint32, mac net.HardwareAddr) error {
+
+	if len(mac) > vfMacLen {
+		return fmt.Errorf("mac address too long")
+	}
+
+	buf := make([]byte, 4+vfMacLen)
+	binary.LittleEndian.PutUint32(buf[0:4], vf)
+	copy(buf[4:], mac)
+
+	return l.setVf(ctx, IFLA_VF_MAC, buf)
+
+}
+
+// SetVfVlan sets the vlan and 802.1p priority a virtual function is tagged
+// with, a vlan of zero disables tagging.
+func (l *Link) SetVfVlan(ctx *Context, vf, vlan, qos uint32) error {
+
+	return l.setVf(ctx, IFLA_VF_VLAN, vfStruct(vf, vlan, qos))
+
+}
+
+// SetVfRate sets the minimum and maximum transmit rate of a virtual function
+// in Mbps, zero meaning unlimited.
+func (l *Link) SetVfRate(ctx *Context, vf, min, max uint32) error {
+
+	return l.setVf(ctx, IFLA_VF_RATE, vfStruct(vf, min, max))
+
+}
+
+// SetVfSpoofChk enables or disables source address spoof checking for a
+// virtual function.
+func (l *Link) SetVfSpoofChk(ctx *Context, vf uint32, on bool) error {
+
+	return l.setVf(ctx, IFLA_VF_SPOOFCHK, vfStruct(vf, uint32(boolByte(on))))
+
+}
+
+// SetVfTrust sets whether a virtual function is trusted, allowing it to
+// change its mac address and enter promiscuous mode.
+func (l *Link) SetVfTrust(ctx *Context, vf uint32, on bool) error {
+
+	return l.setVf(ctx, IFLA_VF_TRUST, vfStruct(vf, uint32(boolByte(on))))
+
+}
+
+// SetVfLinkState sets whether the link of a virtual function follows the
+// physical function, or is forced up or down.
+func (l *Link) SetVfLinkState(ctx *Context, vf uint32, state VfLinkState) error {
+
+	return l.setVf(ctx, IFLA_VF_LINK_STATE, vfStruct(vf, uint32(state)))
+
+}
+
+func (l *Link) setVf(ctx *Context, typ uint16, data []byte) error {
+
+	if l.Msg.Index == 0 {
+		return fmt.Errorf("link index must be set")
+	}
+
+	attrs, err := vfInfoListBytes(typ, data)
+	if err != nil {
+		log.WithError(err).Error("failed to encode vf attributes")
+		return err
+	}
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.RTM_SETLINK),
+			Flags: netlink.Request | netlink.Acknowledge,
+		},
+		Data: append(IfInfomsgBytes(unix.IfInfomsg{Index: l.Msg.Index}), attrs...),
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
+
+}
+
+// vfInfoListBytes wraps a single virtual function attribute in the
+// IFLA_VFINFO_LIST and IFLA_VF_INFO nesting the kernel expects.
+func vfInfoListBytes(typ uint16, data []byte) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_VFINFO_LIST, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Do(IFLA_VF_INFO, func() ([]byte, error) {
+
+			ae2 := netlink.NewAttributeEncoder()
+			ae2.Bytes(typ, data)
+			return ae2.Encode()
+
+		})
+		return ae1.Encode()
+
+	})
+
+	return ae.Encode()
+
+}
+
+// vfStruct lays out the kernel's ifla_vf_* structures, which are a vf number
+// followed by 32 bit values.
+func vfStruct(vf uint32, values ...uint32) []byte {
+
+	buf := make([]byte, 4*(len(values)+1))
+	binary.LittleEndian.PutUint32(buf[0:4], vf)
+	for i, x := range values {
+		binary.LittleEndian.PutUint32(buf[4*(i+1):], x)
+	}
+	return buf
+
+}
+
+func (s VfLinkState) String() string {
+
+	switch s {
+	case IFLA_VF_LINK_STATE_AUTO:
+		return "auto"
+	case IFLA_VF_LINK_STATE_ENABLE:
+		return "enable"
+	case IFLA_VF_LINK_STATE_DISABLE:
+		return "disable"
+	default:
+		return "unknown"
+	}
+
+}
+
+func ParseVfLinkState(state string) (VfLinkState, error) {
+
+	switch state {
+	case "auto":
+		return IFLA_VF_LINK_STATE_AUTO, nil
+	case "enable":
+		return IFLA_VF_LINK_STATE_ENABLE, nil
+	case "disable":
+		return IFLA_VF_LINK_STATE_DISABLE, nil
+	}
+
+	return 0, fmt.Errorf("undefined vf link state")
+
+}
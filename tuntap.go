@@ -1,43 +1,121 @@
 package rtnl
 
 import (
+	"fmt"
+	"os"
+	"runtime"
+	"unsafe"
+
 	"github.com/mdlayher/netlink"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
 
+// tun attribute types
+const (
+	IFLA_TUN_UNSPEC uint16 = iota
+	IFLA_TUN_OWNER
+	IFLA_TUN_GROUP
+	IFLA_TUN_TYPE
+	IFLA_TUN_PI
+	IFLA_TUN_VNET_HDR
+	IFLA_TUN_PERSIST
+	IFLA_TUN_MULTI_QUEUE
+	IFLA_TUN_NUM_QUEUES
+	IFLA_TUN_NUM_DISABLED_QUEUES
+)
+
+const (
+	tunDevice = "/dev/net/tun"
+	ifReqLen  = 40
+)
+
+// TunTap holds the properties shared by tun and tap devices. The kernel does
+// not create these devices through rtnetlink, so they are created through
+// /dev/net/tun and made persistent so they outlive the creating process.
+type TunTap struct {
+	// the user and group allowed to attach to the device, unrestricted if nil
+	Owner *uint32
+	Group *uint32
+
+	// prefix packets with struct tun_pi packet information
+	PacketInfo bool
+
+	// prefix packets with a struct virtio_net_hdr
+	VnetHdr bool
+
+	// allow attaching multiple queues to the device
+	MultiQueue bool
+
+	// number of queues to attach at creation, one if zero, only meaningful
+	// for multi-queue devices, not filled in on read
+	NumQueues uint32
+
+	// when set the queues attached at creation are kept open and returned in
+	// Queues for use by userspace datapaths
+	KeepQueues bool
+	Queues     []*os.File
+
+	// read only state
+	Persist           bool
+	AttachedQueues    uint32
+	NumDisabledQueues uint32
+}
+
 // Tap ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 
 type Tap struct {
+	TunTap
 }
 
 func (t *Tap) Marshal(ctx *Context) ([]byte, error) {
 
-	ae := netlink.NewAttributeEncoder()
-	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+	return t.marshal()
 
-		ae1 := netlink.NewAttributeEncoder()
-		ae1.Bytes(IFLA_INFO_KIND, []byte("tap"))
+}
 
-		return ae1.Encode()
+func (t *Tap) Unmarshal(ctx *Context, buf []byte) error {
 
-	})
+	return t.unmarshal(buf)
 
-	return ae.Encode()
+}
+
+func (t *Tap) Resolve(ctx *Context) error {
+
+	return nil
 
 }
 
-func (t *Tap) Unmarshal(ctx *Context, buf []byte) error {
+// Tun ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 
-	ad, err := netlink.NewAttributeDecoder(buf)
+type Tun struct {
+	TunTap
+
+	// the kernel reports tap devices as tun devices, this holds the actual
+	// device type when read
+	typ uint8
+}
+
+func (t *Tun) Marshal(ctx *Context) ([]byte, error) {
+
+	return t.marshal()
+
+}
+
+func (t *Tun) Unmarshal(ctx *Context, buf []byte) error {
+
+	err := t.unmarshal(buf)
 	if err != nil {
-		log.WithError(err).Error("failed to tap attribute decoder")
 		return err
 	}
 
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return err
+	}
 	for ad.Next() {
-		switch ad.Type() {
-		//TODO
+		if ad.Type() == IFLA_TUN_TYPE {
+			t.typ = ad.Uint8()
 		}
 	}
 
@@ -45,53 +123,293 @@ func (t *Tap) Unmarshal(ctx *Context, buf []byte) error {
 
 }
 
-func (t *Tap) Resolve(ctx *Context) error {
+func (t *Tun) Resolve(ctx *Context) error {
 
 	return nil
 
 }
 
-// Tun ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+// Shared ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// marshal encodes nothing. Tun and tap devices are created through
+// /dev/net/tun by addTunTap, and the kernel does not change their properties
+// over rtnetlink, so any link message they are part of is not a create and
+// carries no tun attributes.
+func (t *TunTap) marshal() ([]byte, error) {
+
+	return nil, nil
 
-type Tun struct {
 }
 
-func (t *Tun) Marshal(ctx *Context) ([]byte, error) {
+func (t *TunTap) unmarshal(buf []byte) error {
 
-	ae := netlink.NewAttributeEncoder()
-	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create tun attribute decoder")
+		return err
+	}
 
-		ae1 := netlink.NewAttributeEncoder()
-		ae1.Bytes(IFLA_INFO_KIND, []byte("tun"))
+	for ad.Next() {
+		switch ad.Type() {
 
-		return ae1.Encode()
+		// the kernel reports an owner and group of -1 when unrestricted
+		case IFLA_TUN_OWNER:
+			if owner := ad.Uint32(); owner != ^uint32(0) {
+				t.Owner = &owner
+			}
 
-	})
+		case IFLA_TUN_GROUP:
+			if group := ad.Uint32(); group != ^uint32(0) {
+				t.Group = &group
+			}
 
-	return ae.Encode()
+		case IFLA_TUN_PI:
+			t.PacketInfo = ad.Uint8() != 0
+
+		case IFLA_TUN_VNET_HDR:
+			t.VnetHdr = ad.Uint8() != 0
+
+		case IFLA_TUN_PERSIST:
+			t.Persist = ad.Uint8() != 0
+
+		case IFLA_TUN_MULTI_QUEUE:
+			t.MultiQueue = ad.Uint8() != 0
+
+		case IFLA_TUN_NUM_QUEUES:
+			t.AttachedQueues = ad.Uint32()
+
+		case IFLA_TUN_NUM_DISABLED_QUEUES:
+			t.NumDisabledQueues = ad.Uint32()
+
+		}
+	}
+
+	return nil
 
 }
 
-func (t *Tun) Unmarshal(ctx *Context, buf []byte) error {
+// create makes a persistent tun or tap device with the provided name in the
+// namespace of the provided context, and returns the name the kernel gave
+// the device.
+func (t *TunTap) create(ctx *Context, name string, typ uint16) (string, error) {
 
-	ad, err := netlink.NewAttributeDecoder(buf)
+	flags := typ
+	if !t.PacketInfo {
+		flags |= unix.IFF_NO_PI
+	}
+	if t.VnetHdr {
+		flags |= unix.IFF_VNET_HDR
+	}
+
+	queues := uint32(1)
+	if t.MultiQueue {
+		flags |= unix.IFF_MULTI_QUEUE
+		if t.NumQueues > 1 {
+			queues = t.NumQueues
+		}
+	}
+
+	var files []*os.File
+	closeAll := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+
+	err := withNs(ctx.Fd(), func() error {
+
+		for i := uint32(0); i < queues; i++ {
+
+			// the device is created in the namespace /dev/net/tun is opened in
+			f, err := os.OpenFile(tunDevice, os.O_RDWR, 0)
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+
+			name, err = tunSetIff(f, name, flags)
+			if err != nil {
+				return err
+			}
+
+		}
+
+		return nil
+
+	})
 	if err != nil {
-		log.WithError(err).Error("failed to tun attribute decoder")
-		return err
+		closeAll()
+		return "", err
 	}
 
-	for ad.Next() {
-		switch ad.Type() {
-		//TODO
+	fd := files[0].Fd()
+
+	if t.Owner != nil {
+		err = unix.IoctlSetInt(int(fd), unix.TUNSETOWNER, int(*t.Owner))
+		if err != nil {
+			closeAll()
+			return "", fmt.Errorf("tun set owner: %v", err)
 		}
 	}
 
-	return nil
+	if t.Group != nil {
+		err = unix.IoctlSetInt(int(fd), unix.TUNSETGROUP, int(*t.Group))
+		if err != nil {
+			closeAll()
+			return "", fmt.Errorf("tun set group: %v", err)
+		}
+	}
+
+	err = unix.IoctlSetInt(int(fd), unix.TUNSETPERSIST, 1)
+	if err != nil {
+		closeAll()
+		return "", fmt.Errorf("tun set persist: %v", err)
+	}
+
+	if t.KeepQueues {
+		t.Queues = files
+	} else {
+		closeAll()
+	}
+
+	return name, nil
 
 }
 
-func (t *Tun) Resolve(ctx *Context) error {
+// tunSetIff attaches the open tun file to the named device, creating it if it
+// does not exist.
+func tunSetIff(f *os.File, name string, flags uint16) (string, error) {
 
-	return nil
+	if len(name) >= unix.IFNAMSIZ {
+		return "", fmt.Errorf("interface name too long")
+	}
+
+	var ifr [ifReqLen]byte
+	copy(ifr[:unix.IFNAMSIZ], name)
+	*(*uint16)(unsafe.Pointer(&ifr[unix.IFNAMSIZ])) = flags
+
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		f.Fd(),
+		uintptr(unix.TUNSETIFF),
+		uintptr(unsafe.Pointer(&ifr[0])),
+	)
+	if errno != 0 {
+		return "", fmt.Errorf("tun set iff: %v", errno)
+	}
+
+	n := 0
+	for n < unix.IFNAMSIZ && ifr[n] != 0 {
+		n++
+	}
+	return string(ifr[:n]), nil
+
+}
+
+// withNs runs f on a thread in the namespace referred to by ns. The work is
+// done on its own goroutine so that, should restoring the original namespace
+// fail, the thread stays locked and is discarded when the goroutine exits
+// rather than going back to the scheduler in the wrong namespace.
+func withNs(ns int, f func() error) error {
+
+	if ns == 0 {
+		return f()
+	}
+
+	result := make(chan error, 1)
+	go func() {
+
+		runtime.LockOSThread()
+
+		thisNS, err := os.Open(
+			fmt.Sprintf("/proc/%d/task/%d/ns/net", os.Getpid(), unix.Gettid()))
+		if err != nil {
+			runtime.UnlockOSThread()
+			log.WithError(err).Error("failed to open this netns")
+			result <- fmt.Errorf("failed to open netns")
+			return
+		}
+		defer thisNS.Close()
+
+		err = unix.Setns(ns, unix.CLONE_NEWNET)
+		if err != nil {
+			runtime.UnlockOSThread()
+			result <- err
+			return
+		}
+
+		ferr := f()
+
+		err = unix.Setns(int(thisNS.Fd()), unix.CLONE_NEWNET)
+		if err != nil {
+			log.WithError(err).Error("failed to restore netns")
+			result <- fmt.Errorf("failed to restore netns: %v", err)
+			return
+		}
+
+		runtime.UnlockOSThread()
+		result <- ferr
+
+	}()
+
+	return <-result
+
+}
+
+// tunTap returns the tun or tap properties of the link and the corresponding
+// device type, or nil if the link is neither.
+func (l *Link) tunTap() (*TunTap, uint16) {
+
+	if l.Info == nil {
+		return nil, 0
+	}
+	if l.Info.Tap != nil {
+		return &l.Info.Tap.TunTap, unix.IFF_TAP
+	}
+	if l.Info.Tun != nil {
+		return &l.Info.Tun.TunTap, unix.IFF_TUN
+	}
+	return nil, 0
+
+}
+
+// addTunTap creates a tun or tap link through /dev/net/tun and applies the
+// remaining link properties with a set.
+func (l *Link) addTunTap(ctx *Context, t *TunTap, typ uint16) error {
+
+	name, err := t.create(ctx, l.Info.Name, typ)
+	if err != nil {
+		return err
+	}
+	l.Info.Name = name
+
+	info := *l.Info
+	info.Ns = 0
+	info.NsPid = 0
+
+	ae := netlink.NewAttributeEncoder()
+	info.encodeCommon(ae)
+	if info.Master != 0 {
+		ae.Uint32(unix.IFLA_MASTER, info.Master)
+	}
+	if l.Info.Ns != 0 {
+		ae.Uint32(unix.IFLA_NET_NS_FD, l.Info.Ns)
+	} else if l.Info.NsPid != 0 {
+		ae.Uint32(unix.IFLA_NET_NS_PID, l.Info.NsPid)
+	}
+	attrs, err := ae.Encode()
+	if err != nil {
+		return err
+	}
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.RTM_SETLINK),
+			Flags: netlink.Request | netlink.Acknowledge,
+		},
+		Data: append(IfInfomsgBytes(unix.IfInfomsg{}), attrs...),
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
 
 }
@@ -3,8 +3,14 @@ package rtnl
 import (
 	"encoding/binary"
 	"net"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
 )
 
+// nlaTypeMask strips the nested and byte order flags from an attribute type
+const nlaTypeMask = ^uint16(unix.NLA_F_NESTED | unix.NLA_F_NET_BYTEORDER)
+
 func htons(val uint16) uint16 {
 	buf := make([]byte, 2)
 	binary.LittleEndian.PutUint16(buf, val)
@@ -17,6 +23,61 @@ func ntohs(val uint16) uint16 {
 	return binary.LittleEndian.Uint16(buf)
 }
 
+func htonl(val uint32) uint32 {
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, val)
+	return binary.BigEndian.Uint32(buf)
+}
+
+func ntohl(val uint32) uint32 {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, val)
+	return binary.LittleEndian.Uint32(buf)
+}
+
+func boolByte(v bool) uint8 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func decodeBool(ad *netlink.AttributeDecoder) *bool {
+	v := ad.Uint8() != 0
+	return &v
+}
+
+// ipBytes returns the 4 byte form of v4 addresses and 16 byte form otherwise
+func ipBytes(ip net.IP) []byte {
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip.To16()
+
+}
+
+// forEachNested calls f with the payload of each attribute of type typ in buf.
+func forEachNested(buf []byte, typ uint16, f func([]byte) error) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return err
+	}
+
+	for ad.Next() {
+		if ad.Type()&nlaTypeMask == typ {
+			err := f(ad.Bytes())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return ad.Err()
+
+}
+
 func isZeroMac(mac net.HardwareAddr) bool {
 
 	for _, x := range mac {
@@ -0,0 +1,40 @@
+package rtnl
+
+import (
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Vcan encapsulates virtual CAN links, which loop frames back to local
+// readers like a CAN bus without other nodes.
+type Vcan struct {
+}
+
+func (t *Vcan) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte("vcan"))
+
+		return ae1.Encode()
+
+	})
+
+	return ae.Encode()
+
+}
+
+// Unmarshal is a no-op, vcan links carry no kind specific attributes.
+func (t *Vcan) Unmarshal(ctx *Context, buf []byte) error {
+
+	return nil
+
+}
+
+func (t *Vcan) Resolve(ctx *Context) error {
+
+	return nil
+
+}
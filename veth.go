@@ -2,8 +2,10 @@ package rtnl
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
@@ -14,37 +16,64 @@ const (
 	VETH_INFO_PEER
 )
 
-// Veth encapsulates information about virtual ethernet devices
+// Veth encapsulates information about virtual ethernet devices. Reading a veth
+// fills in the name of its peer when the peer is in the same namespace. When
+// the peer is in another namespace only its index and namespace id are known,
+// ResolvePeerNamespace finds the peer's namespace and name by querying every
+// named namespace, so it is only done on request.
 type Veth struct {
 	Peer    string
 	PeerIfx uint32
-	PeerNS  uint32
+
+	// PeerNS is the id of the namespace the peer lives in, it is only valid
+	// when PeerRemote is set. Resolving the peer fills in the namespace name.
+	PeerNS        uint32
+	PeerRemote    bool
+	PeerNamespace string
+
+	// PeerInfo holds the properties the peer is created with, such as its
+	// namespace, address, mtu and alias. When its name is empty Peer is used.
+	PeerInfo *LinkInfo
 }
 
 // Marshal turns a veth into a binary rtnetlink set of attributes.
 func (v *Veth) Marshal(ctx *Context) ([]byte, error) {
 
+	return v.marshal("veth")
+
+}
+
+// marshal encodes a peered link of the provided kind, the peer attribute is
+// shared by veth and vxcan links.
+func (v *Veth) marshal(kind string) ([]byte, error) {
+
 	ae := netlink.NewAttributeEncoder()
 	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
 
 		ae1 := netlink.NewAttributeEncoder()
-		ae1.Bytes(IFLA_INFO_KIND, []byte("veth"))
+		ae1.Bytes(IFLA_INFO_KIND, []byte(kind))
 		ae1.Do(IFLA_INFO_DATA, func() ([]byte, error) {
 
 			ae2 := netlink.NewAttributeEncoder()
 			ae2.Do(VETH_INFO_PEER, func() ([]byte, error) {
 
+				peer := LinkInfo{}
+				if v.PeerInfo != nil {
+					peer = *v.PeerInfo
+				}
+				if peer.Name == "" {
+					peer.Name = v.Peer
+				}
+
 				ae3 := netlink.NewAttributeEncoder()
-				ae3.String(unix.IFLA_IFNAME, v.Peer)
+				peer.encodeCommon(ae3)
 				buf, err := ae3.Encode()
 
-				// VETH_INFO_PEER seems to requre sufficient leading padding to hold an
-				// ifinfomsg, probably to contain ifinfo about the peer interface, this
-				// information was gleaned off looking at other netlink code that deals
-				// with veths such as iproute2 and lxc, I cannot find any documentation
-				// that says this is how it is.
-				pad := make([]byte, ifInfomsgLen)
-				buf = append(pad, buf...)
+				// VETH_INFO_PEER carries a struct ifinfomsg for the peer followed by
+				// the peer's link attributes, the kernel creates the peer from these
+				// in the same way it creates the link itself
+				msg := IfInfomsgBytes(unix.IfInfomsg{})
+				buf = append(msg, buf...)
 				return buf, err
 
 			})
@@ -58,7 +87,7 @@ func (v *Veth) Marshal(ctx *Context) ([]byte, error) {
 	})
 	attrbuf, err := ae.Encode()
 	if err != nil {
-		log.WithError(err).Error("failed to encode veth attributes")
+		log.WithError(err).Errorf("failed to encode %s attributes", kind)
 		return nil, err
 	}
 
@@ -79,7 +108,14 @@ func (v *Veth) Unmarshal(ctx *Context, buf []byte) error {
 
 		case VETH_INFO_PEER:
 
-			ad1, err := netlink.NewAttributeDecoder(ad.Bytes())
+			// the peer attributes follow the peer's ifinfomsg
+			buf := ad.Bytes()
+			if len(buf) < ifInfomsgLen {
+				continue
+			}
+			v.PeerIfx = nlenc.Uint32(buf[4:8])
+
+			ad1, err := netlink.NewAttributeDecoder(buf[ifInfomsgLen:])
 			if err != nil {
 				return err
 			}
@@ -91,8 +127,8 @@ func (v *Veth) Unmarshal(ctx *Context, buf []byte) error {
 					v.Peer = ad1.String()
 
 				case unix.IFLA_LINK_NETNSID:
-					v.PeerNS = ad.Uint32()
-					log.Printf("veth link netnsid %d", v.PeerNS)
+					v.PeerNS = ad1.Uint32()
+					v.PeerRemote = true
 
 				}
 			}
@@ -100,7 +136,6 @@ func (v *Veth) Unmarshal(ctx *Context, buf []byte) error {
 		}
 	}
 
-	//return v.ResolvePeer(ctx)
 	return nil
 
 }
@@ -135,7 +170,7 @@ func (v *Veth) ResolvePeer(ctx *Context) error {
 
 	spec := NewLink()
 	spec.Msg.Index = int32(v.PeerIfx)
-	result, err := ReadLinks(ctx, spec)
+	result, err := readLinks(ctx, spec)
 	if err != nil {
 		log.WithFields(fields).WithError(err).Error("read peer failed")
 		return err
@@ -155,12 +190,62 @@ func (v *Veth) ResolvePeer(ctx *Context) error {
 
 }
 
-// Reolve handle attributes
+// Resolve fills in the name of the peer when it lives in the namespace of the
+// provided context. A peer in another namespace is left to
+// ResolvePeerNamespace.
 func (v *Veth) Resolve(ctx *Context) error {
 
-	//TODO(ry): broken across namespaces
-	return nil
+	if v.PeerIfx == 0 || v.PeerRemote {
+		return nil
+	}
 
+	v.PeerNamespace = ""
 	return v.ResolvePeer(ctx)
 
 }
+
+// ResolvePeerNamespace fills in the name of the peer, and the name of the
+// namespace it lives in when that is not the namespace of the provided context.
+// Finding the namespace means querying every named namespace, so this is not
+// done on every read.
+func (v *Veth) ResolvePeerNamespace(ctx *Context) error {
+
+	if v.PeerIfx == 0 || !v.PeerRemote {
+		return v.Resolve(ctx)
+	}
+
+	pctx, name, err := FindNamespace(ctx, int32(v.PeerNS))
+	if err != nil {
+		// the peer may live in an anonymous namespace we have no handle on
+		log.WithFields(log.Fields{
+			"nsid": v.PeerNS,
+		}).WithError(err).Debug("veth peer namespace not found")
+		return nil
+	}
+	defer pctx.Close()
+
+	v.PeerNamespace = name
+	return v.ResolvePeer(pctx)
+
+}
+
+// withPeerNs calls f with a file descriptor for the namespace the peer
+// described by info was created in.
+func withPeerNs(ctx *Context, info *LinkInfo, f func(int) error) error {
+
+	if info.Ns != 0 {
+		return f(int(info.Ns))
+	}
+
+	if info.NsPid != 0 {
+		ns, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", info.NsPid))
+		if err != nil {
+			return err
+		}
+		defer ns.Close()
+		return f(int(ns.Fd()))
+	}
+
+	return f(ctx.Fd())
+
+}
@@ -1,6 +1,8 @@
 package rtnl
 
 import (
+	"fmt"
+
 	"github.com/mdlayher/netlink"
 	"golang.org/x/sys/unix"
 )
@@ -19,6 +21,14 @@ type Vrf struct {
 	Table uint32
 }
 
+// VrfPort holds the properties of a link enslaved to a vrf
+type VrfPort struct {
+	Table uint32
+}
+
+// the preference the kernel installs the l3mdev rule with
+const l3mdevRulePriority = 1000
+
 func (v *Vrf) Marshal(ctx *Context) ([]byte, error) {
 
 	ae := netlink.NewAttributeEncoder()
@@ -68,3 +78,149 @@ func (v *Vrf) Resolve(ctx *Context) error {
 	return nil
 
 }
+
+func (p *VrfPort) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_VRF_PORT_TABLE:
+			p.Table = ad.Uint32()
+
+		}
+	}
+
+	return nil
+
+}
+
+// GetVrf reads the named vrf link.
+func GetVrf(ctx *Context, name string) (*Link, error) {
+
+	lnk, err := GetLink(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if lnk.Info.Vrf == nil {
+		return nil, fmt.Errorf("%s is not a vrf", name)
+	}
+
+	return lnk, nil
+
+}
+
+// VrfEnslave enslaves the named interface to the named vrf.
+func VrfEnslave(ctx *Context, vrf, iface string) error {
+
+	v, err := GetVrf(ctx, vrf)
+	if err != nil {
+		return err
+	}
+
+	lnk, err := GetLink(ctx, iface)
+	if err != nil {
+		return err
+	}
+
+	return lnk.SetMaster(ctx, int(v.Msg.Index))
+
+}
+
+// VrfRelease releases the named interface from the vrf it is enslaved to.
+func VrfRelease(ctx *Context, iface string) error {
+
+	lnk, err := GetLink(ctx, iface)
+	if err != nil {
+		return err
+	}
+
+	if lnk.Info.VrfPort == nil {
+		return fmt.Errorf("%s is not enslaved to a vrf", iface)
+	}
+
+	return lnk.UnsetMaster(ctx)
+
+}
+
+// VrfMembers reads the links enslaved to the named vrf.
+func VrfMembers(ctx *Context, vrf string) ([]*Link, error) {
+
+	v, err := GetVrf(ctx, vrf)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := ReadLinks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Link
+	for _, l := range links {
+		if l.Info.Master == uint32(v.Msg.Index) {
+			result = append(result, l)
+		}
+	}
+
+	return result, nil
+
+}
+
+// VrfRoutes reads the routes of the provided address family in the table of
+// the named vrf.
+func VrfRoutes(ctx *Context, vrf string, family uint8) ([]*Route, error) {
+
+	v, err := GetVrf(ctx, vrf)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := ReadRoutes(ctx, &Route{
+		Hdr:   unix.RtMsg{Family: family},
+		Table: v.Info.Vrf.Table,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Route
+	for _, r := range routes {
+		if r.Table == v.Info.Vrf.Table {
+			result = append(result, r)
+		}
+	}
+
+	return result, nil
+
+}
+
+// EnsureL3mdevRule ensures the rule that directs lookups of packets associated
+// with a vrf to the vrf's table exists for the provided address family.
+func EnsureL3mdevRule(ctx *Context, family uint8) error {
+
+	rules, err := ReadRules(ctx, &Rule{Fib: Fib{Family: family}})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rules {
+		if r.Fib.Family == family && r.L3mdev {
+			return nil
+		}
+	}
+
+	rule := &Rule{
+		Fib:      Fib{Family: family},
+		Priority: l3mdevRulePriority,
+		L3mdev:   true,
+	}
+
+	return rule.Present(ctx)
+
+}
@@ -0,0 +1,155 @@
+package rtnl
+
+import (
+	"net"
+
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// vti attribute types
+const (
+	IFLA_VTI_UNSPEC uint16 = iota
+	IFLA_VTI_LINK
+	IFLA_VTI_IKEY
+	IFLA_VTI_OKEY
+	IFLA_VTI_LOCAL
+	IFLA_VTI_REMOTE
+	IFLA_VTI_FWMARK
+)
+
+// Vti encapsulates information about virtual tunnel interfaces. The input and
+// output keys are used as marks to match the ipsec policies protecting the
+// tunnel. A vti with IPv6 endpoints is created as a vti6 link.
+type Vti struct {
+	Link   uint32 // interface index
+	Ikey   uint32
+	Okey   uint32
+	Local  net.IP
+	Remote net.IP
+	Fwmark uint32
+
+	// set for vti6 links, implied by IPv6 endpoints
+	Ipv6 bool
+}
+
+// Kind returns the link kind of the vti, vti or vti6.
+func (v *Vti) Kind() string {
+
+	if v.Ipv6 {
+		return "vti6"
+	}
+	for _, x := range []net.IP{v.Local, v.Remote} {
+		if x != nil && x.To4() == nil {
+			return "vti6"
+		}
+	}
+	return "vti"
+
+}
+
+// Marshal turns a vti into a binary rtnetlink set of attributes.
+func (v *Vti) Marshal(ctx *Context) ([]byte, error) {
+
+	kind := v.Kind()
+
+	addr := func(ip net.IP) []byte {
+		if kind == "vti" {
+			return ip.To4()
+		}
+		return ip.To16()
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte(kind))
+		ae1.Do(IFLA_INFO_DATA, func() ([]byte, error) {
+
+			ae2 := netlink.NewAttributeEncoder()
+
+			if v.Link != 0 {
+				ae2.Uint32(IFLA_VTI_LINK, v.Link)
+			}
+
+			// keys are carried in network byte order
+			if v.Ikey != 0 {
+				ae2.Uint32(IFLA_VTI_IKEY, htonl(v.Ikey))
+			}
+			if v.Okey != 0 {
+				ae2.Uint32(IFLA_VTI_OKEY, htonl(v.Okey))
+			}
+
+			if v.Local != nil {
+				ae2.Bytes(IFLA_VTI_LOCAL, addr(v.Local))
+			}
+			if v.Remote != nil {
+				ae2.Bytes(IFLA_VTI_REMOTE, addr(v.Remote))
+			}
+
+			if v.Fwmark != 0 {
+				ae2.Uint32(IFLA_VTI_FWMARK, v.Fwmark)
+			}
+
+			return ae2.Encode()
+
+		})
+
+		return ae1.Encode()
+
+	})
+	attrbuf, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode vti attributes")
+		return nil, err
+	}
+
+	return attrbuf, nil
+
+}
+
+// Unmarshal reads a vti from a binary set of attributes.
+func (v *Vti) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create vti attribute decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_VTI_LINK:
+			v.Link = ad.Uint32()
+
+		case IFLA_VTI_IKEY:
+			v.Ikey = ntohl(ad.Uint32())
+
+		case IFLA_VTI_OKEY:
+			v.Okey = ntohl(ad.Uint32())
+
+		case IFLA_VTI_LOCAL:
+			v.Local = net.IP(ad.Bytes())
+
+		case IFLA_VTI_REMOTE:
+			v.Remote = net.IP(ad.Bytes())
+
+		case IFLA_VTI_FWMARK:
+			v.Fwmark = ad.Uint32()
+
+		}
+	}
+
+	return nil
+
+}
+
+// Resolve handle attributes
+func (v *Vti) Resolve(ctx *Context) error {
+
+	return nil
+
+}
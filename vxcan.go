@@ -0,0 +1,21 @@
+package rtnl
+
+// vxcan attribute types
+const (
+	VXCAN_INFO_UNSPEC uint16 = iota
+	VXCAN_INFO_PEER
+)
+
+// Vxcan encapsulates information about virtual CAN tunnels. Like veth
+// devices they come in pairs, typically with one end in another namespace,
+// and the peer is handled in the same way.
+type Vxcan struct {
+	Veth
+}
+
+// Marshal turns a vxcan into a binary rtnetlink set of attributes.
+func (v *Vxcan) Marshal(ctx *Context) ([]byte, error) {
+
+	return v.marshal("vxcan")
+
+}
@@ -1,6 +1,7 @@
 package rtnl
 
 import (
+	"encoding/binary"
 	"net"
 
 	"github.com/mdlayher/netlink"
@@ -43,11 +44,34 @@ const (
 
 // Vxlan encapsulates information about virtual extensible LAN devices.
 type Vxlan struct {
-	Vni      uint32
-	Learning uint8
-	DstPort  uint16
-	Local    net.IP
-	Link     uint32 // interface index
+	Vni             uint32
+	Learning        uint8
+	DstPort         uint16
+	Local           net.IP
+	Group           net.IP // remote or multicast group address
+	Link            uint32 // interface index
+	Ttl             uint8
+	TtlInherit      bool
+	Tos             uint8
+	Ageing          uint32
+	Limit           uint32
+	PortRange       *VxlanPortRange // source port range
+	Proxy           bool
+	Rsc             bool
+	L2miss          bool
+	L3miss          bool
+	UdpCsum         *bool // only sent when set, the kernel default varies
+	UdpZeroCsum6Tx  *bool
+	UdpZeroCsum6Rx  *bool
+	Gbp             bool
+	CollectMetadata bool
+	Label           uint32
+}
+
+// VxlanPortRange is the range of UDP source ports a vxlan device uses.
+type VxlanPortRange struct {
+	Low  uint16
+	High uint16
 }
 
 // Marshal turns a vxlan into a binary rtnetlink set of attributes.
@@ -69,14 +93,81 @@ func (v *Vxlan) Marshal(ctx *Context) ([]byte, error) {
 				local := v.Local.To4()
 				if local != nil {
 					ae2.Bytes(IFLA_VXLAN_LOCAL, local)
+				} else {
+					ae2.Bytes(IFLA_VXLAN_LOCAL6, v.Local.To16())
+				}
+			}
+
+			if v.Group != nil {
+				group := v.Group.To4()
+				if group != nil {
+					ae2.Bytes(IFLA_VXLAN_GROUP, group)
+				} else {
+					ae2.Bytes(IFLA_VXLAN_GROUP6, v.Group.To16())
 				}
 			}
-			//TODO ipv6 local
 
 			if v.Link != 0 {
 				ae2.Uint32(IFLA_VXLAN_LINK, v.Link)
 			}
 
+			if v.TtlInherit {
+				ae2.Bytes(IFLA_VXLAN_TTL_INHERIT, []byte{})
+			} else if v.Ttl != 0 {
+				ae2.Uint8(IFLA_VXLAN_TTL, v.Ttl)
+			}
+
+			if v.Tos != 0 {
+				ae2.Uint8(IFLA_VXLAN_TOS, v.Tos)
+			}
+			if v.Ageing != 0 {
+				ae2.Uint32(IFLA_VXLAN_AGEING, v.Ageing)
+			}
+			if v.Limit != 0 {
+				ae2.Uint32(IFLA_VXLAN_LIMIT, v.Limit)
+			}
+
+			if v.PortRange != nil {
+				ae2.Do(IFLA_VXLAN_PORT_RANGE, func() ([]byte, error) {
+					buf := make([]byte, 4)
+					binary.BigEndian.PutUint16(buf[0:2], v.PortRange.Low)
+					binary.BigEndian.PutUint16(buf[2:4], v.PortRange.High)
+					return buf, nil
+				})
+			}
+
+			if v.Proxy {
+				ae2.Uint8(IFLA_VXLAN_PROXY, 1)
+			}
+			if v.Rsc {
+				ae2.Uint8(IFLA_VXLAN_RSC, 1)
+			}
+			if v.L2miss {
+				ae2.Uint8(IFLA_VXLAN_L2MISS, 1)
+			}
+			if v.L3miss {
+				ae2.Uint8(IFLA_VXLAN_L3MISS, 1)
+			}
+			if v.UdpCsum != nil {
+				ae2.Uint8(IFLA_VXLAN_UDP_CSUM, boolByte(*v.UdpCsum))
+			}
+			if v.UdpZeroCsum6Tx != nil {
+				ae2.Uint8(IFLA_VXLAN_UDP_ZERO_CSUM6_TX, boolByte(*v.UdpZeroCsum6Tx))
+			}
+			if v.UdpZeroCsum6Rx != nil {
+				ae2.Uint8(IFLA_VXLAN_UDP_ZERO_CSUM6_RX, boolByte(*v.UdpZeroCsum6Rx))
+			}
+			if v.Gbp {
+				ae2.Bytes(IFLA_VXLAN_GBP, []byte{})
+			}
+			if v.CollectMetadata {
+				ae2.Uint8(IFLA_VXLAN_COLLECT_METADATA, 1)
+			}
+
+			if v.Label != 0 {
+				ae2.Uint32(IFLA_VXLAN_LABEL, htonl(v.Label))
+			}
+
 			return ae2.Encode()
 
 		})
@@ -115,12 +206,72 @@ func (v *Vxlan) Unmarshal(ctx *Context, buf []byte) error {
 		case IFLA_VXLAN_PORT:
 			v.DstPort = ntohs(ad.Uint16())
 
-		case IFLA_VXLAN_LOCAL:
+		case IFLA_VXLAN_LOCAL, IFLA_VXLAN_LOCAL6:
 			v.Local = net.IP(ad.Bytes())
 
+		case IFLA_VXLAN_GROUP, IFLA_VXLAN_GROUP6:
+			v.Group = net.IP(ad.Bytes())
+
 		case IFLA_VXLAN_LINK:
 			v.Link = ad.Uint32()
 
+		case IFLA_VXLAN_TTL:
+			v.Ttl = ad.Uint8()
+
+		case IFLA_VXLAN_TTL_INHERIT:
+			// sent as a flag, reported back as a u8
+			buf := ad.Bytes()
+			v.TtlInherit = len(buf) == 0 || buf[0] != 0
+
+		case IFLA_VXLAN_TOS:
+			v.Tos = ad.Uint8()
+
+		case IFLA_VXLAN_AGEING:
+			v.Ageing = ad.Uint32()
+
+		case IFLA_VXLAN_LIMIT:
+			v.Limit = ad.Uint32()
+
+		case IFLA_VXLAN_PORT_RANGE:
+			// a zero range means the kernel picks from the local port range
+			buf := ad.Bytes()
+			if len(buf) >= 4 && (buf[0]|buf[1]|buf[2]|buf[3]) != 0 {
+				v.PortRange = &VxlanPortRange{
+					Low:  binary.BigEndian.Uint16(buf[0:2]),
+					High: binary.BigEndian.Uint16(buf[2:4]),
+				}
+			}
+
+		case IFLA_VXLAN_PROXY:
+			v.Proxy = ad.Uint8() != 0
+
+		case IFLA_VXLAN_RSC:
+			v.Rsc = ad.Uint8() != 0
+
+		case IFLA_VXLAN_L2MISS:
+			v.L2miss = ad.Uint8() != 0
+
+		case IFLA_VXLAN_L3MISS:
+			v.L3miss = ad.Uint8() != 0
+
+		case IFLA_VXLAN_UDP_CSUM:
+			v.UdpCsum = decodeBool(ad)
+
+		case IFLA_VXLAN_UDP_ZERO_CSUM6_TX:
+			v.UdpZeroCsum6Tx = decodeBool(ad)
+
+		case IFLA_VXLAN_UDP_ZERO_CSUM6_RX:
+			v.UdpZeroCsum6Rx = decodeBool(ad)
+
+		case IFLA_VXLAN_GBP:
+			v.Gbp = true
+
+		case IFLA_VXLAN_COLLECT_METADATA:
+			v.CollectMetadata = ad.Uint8() != 0
+
+		case IFLA_VXLAN_LABEL:
+			v.Label = ntohl(ad.Uint32())
+
 		}
 	}
 
@@ -1,11 +1,78 @@
 package rtnl
 
 import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
 	"github.com/mdlayher/netlink"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
 
+const (
+	wgFamilyName = "wireguard"
+	wgKeyLen     = 32
+)
+
+// wireguard generic netlink commands
+const (
+	WG_CMD_GET_DEVICE uint8 = iota
+	WG_CMD_SET_DEVICE
+)
+
+// wireguard device attribute types
+const (
+	WGDEVICE_A_UNSPEC uint16 = iota
+	WGDEVICE_A_IFINDEX
+	WGDEVICE_A_IFNAME
+	WGDEVICE_A_PRIVATE_KEY
+	WGDEVICE_A_PUBLIC_KEY
+	WGDEVICE_A_FLAGS
+	WGDEVICE_A_LISTEN_PORT
+	WGDEVICE_A_FWMARK
+	WGDEVICE_A_PEERS
+)
+
+// wireguard device flags
+const (
+	WGDEVICE_F_REPLACE_PEERS = 1 << 0
+)
+
+// wireguard peer attribute types
+const (
+	WGPEER_A_UNSPEC uint16 = iota
+	WGPEER_A_PUBLIC_KEY
+	WGPEER_A_PRESHARED_KEY
+	WGPEER_A_FLAGS
+	WGPEER_A_ENDPOINT
+	WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL
+	WGPEER_A_LAST_HANDSHAKE_TIME
+	WGPEER_A_RX_BYTES
+	WGPEER_A_TX_BYTES
+	WGPEER_A_ALLOWEDIPS
+	WGPEER_A_PROTOCOL_VERSION
+)
+
+// wireguard peer flags
+const (
+	WGPEER_F_REMOVE_ME          = 1 << 0
+	WGPEER_F_REPLACE_ALLOWEDIPS = 1 << 1
+	WGPEER_F_UPDATE_ONLY        = 1 << 2
+)
+
+// wireguard allowed ip attribute types
+const (
+	WGALLOWEDIP_A_UNSPEC uint16 = iota
+	WGALLOWEDIP_A_FAMILY
+	WGALLOWEDIP_A_IPADDR
+	WGALLOWEDIP_A_CIDR_MASK
+)
+
+// Wireguard link ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
 type Wireguard struct {
 }
 
@@ -47,4 +114,421 @@ func (t *Wireguard) Resolve(ctx *Context) error {
 
 	return nil
 
-}
\ No newline at end of file
+}
+
+// Wireguard configuration ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+
+// WireguardKey is a curve25519 key
+type WireguardKey [wgKeyLen]byte
+
+// WireguardDevice is the configuration of a wireguard link, managed over the
+// wireguard generic netlink family. When set, only the fields that are set
+// are changed.
+type WireguardDevice struct {
+	Ifindex      uint32
+	Name         string
+	PrivateKey   *WireguardKey
+	ListenPort   *uint16
+	Fwmark       *uint32
+	ReplacePeers bool
+	Peers        []WireguardPeer
+
+	// read only state
+	PublicKey WireguardKey
+}
+
+// WireguardPeer is a peer of a wireguard device.
+type WireguardPeer struct {
+	PublicKey         WireguardKey
+	PresharedKey      *WireguardKey
+	Endpoint          *net.UDPAddr
+	KeepaliveInterval *uint16 // seconds, 0 disables
+	AllowedIPs        []net.IPNet
+
+	// update flags
+	ReplaceAllowedIPs bool
+	Remove            bool
+	UpdateOnly        bool
+
+	// read only state
+	LastHandshake   time.Time
+	RxBytes         uint64
+	TxBytes         uint64
+	ProtocolVersion uint32
+}
+
+// ReadWireguardDevice reads the configuration and peers of the named wireguard
+// link.
+func ReadWireguardDevice(ctx *Context, name string) (*WireguardDevice, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.String(WGDEVICE_A_IFNAME, name)
+	attrs, err := ae.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ctx.GenlExecute(wgFamilyName, WG_CMD_GET_DEVICE,
+		netlink.Request|netlink.Dump, attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	// devices with many peers are split across several messages
+	dev := &WireguardDevice{}
+	for _, r := range resp {
+		err := dev.Unmarshal(ctx, r.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dev, nil
+
+}
+
+// Set applies the wireguard device configuration to the kernel.
+func (d *WireguardDevice) Set(ctx *Context) error {
+
+	attrs, err := d.Marshal(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to marshal wireguard device")
+		return err
+	}
+
+	_, err = ctx.GenlExecute(wgFamilyName, WG_CMD_SET_DEVICE,
+		netlink.Request|netlink.Acknowledge, attrs)
+	return err
+
+}
+
+// Marshal turns a wireguard device into a binary set of generic netlink
+// attributes.
+func (d *WireguardDevice) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+
+	if d.Ifindex != 0 {
+		ae.Uint32(WGDEVICE_A_IFINDEX, d.Ifindex)
+	} else {
+		ae.String(WGDEVICE_A_IFNAME, d.Name)
+	}
+	if d.PrivateKey != nil {
+		ae.Bytes(WGDEVICE_A_PRIVATE_KEY, d.PrivateKey[:])
+	}
+	if d.ListenPort != nil {
+		ae.Uint16(WGDEVICE_A_LISTEN_PORT, *d.ListenPort)
+	}
+	if d.Fwmark != nil {
+		ae.Uint32(WGDEVICE_A_FWMARK, *d.Fwmark)
+	}
+	if d.ReplacePeers {
+		ae.Uint32(WGDEVICE_A_FLAGS, WGDEVICE_F_REPLACE_PEERS)
+	}
+
+	if len(d.Peers) > 0 {
+		ae.Do(WGDEVICE_A_PEERS|unix.NLA_F_NESTED, func() ([]byte, error) {
+
+			ae1 := netlink.NewAttributeEncoder()
+			for i := range d.Peers {
+				ae1.Do(uint16(i)|unix.NLA_F_NESTED, d.Peers[i].marshal)
+			}
+			return ae1.Encode()
+
+		})
+	}
+
+	return ae.Encode()
+
+}
+
+func (p *WireguardPeer) marshal() ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Bytes(WGPEER_A_PUBLIC_KEY, p.PublicKey[:])
+
+	var flags uint32
+	if p.Remove {
+		flags |= WGPEER_F_REMOVE_ME
+	}
+	if p.ReplaceAllowedIPs {
+		flags |= WGPEER_F_REPLACE_ALLOWEDIPS
+	}
+	if p.UpdateOnly {
+		flags |= WGPEER_F_UPDATE_ONLY
+	}
+	if flags != 0 {
+		ae.Uint32(WGPEER_A_FLAGS, flags)
+	}
+
+	if p.PresharedKey != nil {
+		ae.Bytes(WGPEER_A_PRESHARED_KEY, p.PresharedKey[:])
+	}
+	if p.Endpoint != nil {
+		ae.Bytes(WGPEER_A_ENDPOINT, sockaddrBytes(p.Endpoint))
+	}
+	if p.KeepaliveInterval != nil {
+		ae.Uint16(WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL, *p.KeepaliveInterval)
+	}
+
+	if p.AllowedIPs != nil {
+		ae.Do(WGPEER_A_ALLOWEDIPS|unix.NLA_F_NESTED, func() ([]byte, error) {
+
+			ae1 := netlink.NewAttributeEncoder()
+			for i, ipn := range p.AllowedIPs {
+				ipn := ipn
+				ae1.Do(uint16(i)|unix.NLA_F_NESTED, func() ([]byte, error) {
+
+					ae2 := netlink.NewAttributeEncoder()
+					ones, _ := ipn.Mask.Size()
+					if ip4 := ipn.IP.To4(); ip4 != nil {
+						ae2.Uint16(WGALLOWEDIP_A_FAMILY, unix.AF_INET)
+						ae2.Bytes(WGALLOWEDIP_A_IPADDR, ip4)
+					} else {
+						ae2.Uint16(WGALLOWEDIP_A_FAMILY, unix.AF_INET6)
+						ae2.Bytes(WGALLOWEDIP_A_IPADDR, ipn.IP.To16())
+					}
+					ae2.Uint8(WGALLOWEDIP_A_CIDR_MASK, uint8(ones))
+					return ae2.Encode()
+
+				})
+			}
+			return ae1.Encode()
+
+		})
+	}
+
+	return ae.Encode()
+
+}
+
+// Unmarshal reads a wireguard device from a binary set of generic netlink
+// attributes. Peers are appended, as a device may span several messages.
+func (d *WireguardDevice) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create wireguard decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+
+		case WGDEVICE_A_IFINDEX:
+			d.Ifindex = ad.Uint32()
+
+		case WGDEVICE_A_IFNAME:
+			d.Name = ad.String()
+
+		case WGDEVICE_A_PRIVATE_KEY:
+			var key WireguardKey
+			copy(key[:], ad.Bytes())
+			d.PrivateKey = &key
+
+		case WGDEVICE_A_PUBLIC_KEY:
+			copy(d.PublicKey[:], ad.Bytes())
+
+		case WGDEVICE_A_LISTEN_PORT:
+			port := ad.Uint16()
+			d.ListenPort = &port
+
+		case WGDEVICE_A_FWMARK:
+			mark := ad.Uint32()
+			d.Fwmark = &mark
+
+		case WGDEVICE_A_PEERS:
+			nad, err := netlink.NewAttributeDecoder(ad.Bytes())
+			if err != nil {
+				return err
+			}
+			for nad.Next() {
+				err := d.unmarshalPeer(nad.Bytes())
+				if err != nil {
+					return err
+				}
+			}
+
+		}
+	}
+
+	return ad.Err()
+
+}
+
+// unmarshalPeer reads a peer, a peer with the same public key as the last peer
+// read continues that peer as its allowed ips may span several messages.
+func (d *WireguardDevice) unmarshalPeer(buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return err
+	}
+
+	p := WireguardPeer{}
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+
+		case WGPEER_A_PUBLIC_KEY:
+			copy(p.PublicKey[:], ad.Bytes())
+
+		case WGPEER_A_PRESHARED_KEY:
+			var key WireguardKey
+			copy(key[:], ad.Bytes())
+			p.PresharedKey = &key
+
+		case WGPEER_A_ENDPOINT:
+			p.Endpoint = parseSockaddr(ad.Bytes())
+
+		case WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL:
+			interval := ad.Uint16()
+			p.KeepaliveInterval = &interval
+
+		case WGPEER_A_LAST_HANDSHAKE_TIME:
+			b := ad.Bytes()
+			if len(b) >= 16 {
+				sec := int64(binary.LittleEndian.Uint64(b[0:8]))
+				nsec := int64(binary.LittleEndian.Uint64(b[8:16]))
+				if sec != 0 || nsec != 0 {
+					p.LastHandshake = time.Unix(sec, nsec)
+				}
+			}
+
+		case WGPEER_A_RX_BYTES:
+			p.RxBytes = ad.Uint64()
+
+		case WGPEER_A_TX_BYTES:
+			p.TxBytes = ad.Uint64()
+
+		case WGPEER_A_PROTOCOL_VERSION:
+			p.ProtocolVersion = ad.Uint32()
+
+		case WGPEER_A_ALLOWEDIPS:
+			nad, err := netlink.NewAttributeDecoder(ad.Bytes())
+			if err != nil {
+				return err
+			}
+			for nad.Next() {
+				ipn, err := unmarshalAllowedIP(nad.Bytes())
+				if err != nil {
+					return err
+				}
+				p.AllowedIPs = append(p.AllowedIPs, ipn)
+			}
+
+		}
+	}
+	if err := ad.Err(); err != nil {
+		return err
+	}
+
+	n := len(d.Peers)
+	if n > 0 && d.Peers[n-1].PublicKey == p.PublicKey {
+		d.Peers[n-1].AllowedIPs = append(d.Peers[n-1].AllowedIPs, p.AllowedIPs...)
+		return nil
+	}
+
+	d.Peers = append(d.Peers, p)
+	return nil
+
+}
+
+func unmarshalAllowedIP(buf []byte) (net.IPNet, error) {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		return net.IPNet{}, err
+	}
+
+	var family uint16
+	var ip net.IP
+	var mask uint8
+	for ad.Next() {
+		switch ad.Type() & nlaTypeMask {
+		case WGALLOWEDIP_A_FAMILY:
+			family = ad.Uint16()
+		case WGALLOWEDIP_A_IPADDR:
+			ip = net.IP(ad.Bytes())
+		case WGALLOWEDIP_A_CIDR_MASK:
+			mask = ad.Uint8()
+		}
+	}
+
+	bits := 128
+	if family == unix.AF_INET {
+		bits = 32
+	}
+
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(int(mask), bits)}, ad.Err()
+
+}
+
+// sockaddrBytes builds a struct sockaddr_in or sockaddr_in6
+func sockaddrBytes(addr *net.UDPAddr) []byte {
+
+	if ip4 := addr.IP.To4(); ip4 != nil {
+		buf := make([]byte, unix.SizeofSockaddrInet4)
+		binary.LittleEndian.PutUint16(buf[0:2], unix.AF_INET)
+		binary.BigEndian.PutUint16(buf[2:4], uint16(addr.Port))
+		copy(buf[4:8], ip4)
+		return buf
+	}
+
+	buf := make([]byte, unix.SizeofSockaddrInet6)
+	binary.LittleEndian.PutUint16(buf[0:2], unix.AF_INET6)
+	binary.BigEndian.PutUint16(buf[2:4], uint16(addr.Port))
+	copy(buf[8:24], addr.IP.To16())
+	return buf
+
+}
+
+// parseSockaddr reads a struct sockaddr_in or sockaddr_in6
+func parseSockaddr(buf []byte) *net.UDPAddr {
+
+	if len(buf) < 4 {
+		return nil
+	}
+
+	port := int(binary.BigEndian.Uint16(buf[2:4]))
+
+	switch binary.LittleEndian.Uint16(buf[0:2]) {
+	case unix.AF_INET:
+		if len(buf) < 8 {
+			return nil
+		}
+		return &net.UDPAddr{IP: net.IP(append([]byte{}, buf[4:8]...)), Port: port}
+	case unix.AF_INET6:
+		if len(buf) < 24 {
+			return nil
+		}
+		return &net.UDPAddr{IP: net.IP(append([]byte{}, buf[8:24]...)), Port: port}
+	}
+
+	return nil
+
+}
+
+// String returns the base64 encoding of the key, as used by wireguard tools.
+func (k WireguardKey) String() string {
+
+	return base64.StdEncoding.EncodeToString(k[:])
+
+}
+
+// ParseWireguardKey parses a base64 encoded wireguard key.
+func ParseWireguardKey(s string) (WireguardKey, error) {
+
+	var key WireguardKey
+
+	buf, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return key, err
+	}
+	if len(buf) != wgKeyLen {
+		return key, fmt.Errorf("wireguard keys must be %d bytes", wgKeyLen)
+	}
+
+	copy(key[:], buf)
+	return key, nil
+
+}
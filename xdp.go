@@ -0,0 +1,199 @@
+package rtnl
+
+import (
+	"fmt"
+
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// xdp attribute types
+const (
+	IFLA_XDP_UNSPEC uint16 = iota
+	IFLA_XDP_FD
+	IFLA_XDP_ATTACHED
+	IFLA_XDP_FLAGS
+	IFLA_XDP_PROG_ID
+	IFLA_XDP_DRV_PROG_ID
+	IFLA_XDP_SKB_PROG_ID
+	IFLA_XDP_HW_PROG_ID
+	IFLA_XDP_EXPECTED_FD
+)
+
+// XDP_FLAGS_REPLACE makes an attach fail unless the program currently
+// attached is the one referred to by IFLA_XDP_EXPECTED_FD
+const XDP_FLAGS_REPLACE uint32 = 1 << 4
+
+// XdpMode aliases the xdp attach modes in a type safe way
+type XdpMode uint32
+
+const (
+	// let the kernel pick, driver mode when supported and generic otherwise
+	XdpModeAuto    XdpMode = 0
+	XdpModeGeneric XdpMode = unix.XDP_FLAGS_SKB_MODE
+	XdpModeDriver  XdpMode = unix.XDP_FLAGS_DRV_MODE
+	XdpModeOffload XdpMode = unix.XDP_FLAGS_HW_MODE
+)
+
+// XdpAttached aliases how programs are attached to a link in a type safe way
+type XdpAttached uint8
+
+const (
+	XDP_ATTACHED_NONE XdpAttached = iota
+	XDP_ATTACHED_DRV
+	XDP_ATTACHED_SKB
+	XDP_ATTACHED_HW
+	XDP_ATTACHED_MULTI
+)
+
+// Xdp holds the xdp programs attached to a link as reported by the kernel.
+// ProgId is only set when a program is attached in a single mode, the per
+// mode ids are set for every attached program.
+type Xdp struct {
+	Attached  XdpAttached
+	ProgId    uint32
+	DrvProgId uint32
+	SkbProgId uint32
+	HwProgId  uint32
+}
+
+// Unmarshal reads xdp state from a binary set of attributes.
+func (x *Xdp) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create xdp attribute decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_XDP_ATTACHED:
+			x.Attached = XdpAttached(ad.Uint8())
+
+		case IFLA_XDP_PROG_ID:
+			x.ProgId = ad.Uint32()
+
+		case IFLA_XDP_DRV_PROG_ID:
+			x.DrvProgId = ad.Uint32()
+
+		case IFLA_XDP_SKB_PROG_ID:
+			x.SkbProgId = ad.Uint32()
+
+		case IFLA_XDP_HW_PROG_ID:
+			x.HwProgId = ad.Uint32()
+
+		}
+	}
+
+	return nil
+
+}
+
+// AttachXdp attaches the loaded bpf program referred to by fd to the link in
+// the provided mode. When expected is not nil the program is only replaced if
+// the one currently attached is the program referred to by expected, a value
+// of -1 requiring that no program is attached.
+func (l *Link) AttachXdp(ctx *Context, fd int, mode XdpMode, expected *int) error {
+
+	return l.setXdp(ctx, int32(fd), mode, expected)
+
+}
+
+// DetachXdp detaches the xdp program attached to the link in the provided
+// mode.
+func (l *Link) DetachXdp(ctx *Context, mode XdpMode) error {
+
+	return l.setXdp(ctx, -1, mode, nil)
+
+}
+
+func (l *Link) setXdp(ctx *Context, fd int32, mode XdpMode, expected *int) error {
+
+	if l.Msg.Index == 0 {
+		return fmt.Errorf("link index must be set")
+	}
+
+	flags := uint32(mode)
+	if expected != nil {
+		flags |= XDP_FLAGS_REPLACE
+	}
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_XDP|unix.NLA_F_NESTED, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_XDP_FD, int32Bytes(fd))
+		if flags != 0 {
+			ae1.Uint32(IFLA_XDP_FLAGS, flags)
+		}
+		if expected != nil {
+			ae1.Bytes(IFLA_XDP_EXPECTED_FD, int32Bytes(int32(*expected)))
+		}
+		return ae1.Encode()
+
+	})
+	attrs, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode xdp attributes")
+		return err
+	}
+
+	m := netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.RTM_SETLINK),
+			Flags: netlink.Request | netlink.Acknowledge,
+		},
+		Data: append(IfInfomsgBytes(unix.IfInfomsg{Index: l.Msg.Index}), attrs...),
+	}
+
+	return netlinkUpdate(ctx, []netlink.Message{m})
+
+}
+
+func int32Bytes(v int32) []byte {
+
+	buf := make([]byte, 4)
+	nlenc.PutInt32(buf, v)
+	return buf
+
+}
+
+func (a XdpAttached) String() string {
+
+	switch a {
+	case XDP_ATTACHED_NONE:
+		return "none"
+	case XDP_ATTACHED_DRV:
+		return "driver"
+	case XDP_ATTACHED_SKB:
+		return "generic"
+	case XDP_ATTACHED_HW:
+		return "offload"
+	case XDP_ATTACHED_MULTI:
+		return "multi"
+	default:
+		return "unknown"
+	}
+
+}
+
+func ParseXdpMode(mode string) (XdpMode, error) {
+
+	switch mode {
+	case "auto":
+		return XdpModeAuto, nil
+	case "generic":
+		return XdpModeGeneric, nil
+	case "driver":
+		return XdpModeDriver, nil
+	case "offload":
+		return XdpModeOffload, nil
+	}
+
+	return 0, fmt.Errorf("undefined xdp mode")
+
+}
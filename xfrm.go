@@ -0,0 +1,100 @@
+package rtnl
+
+import (
+	"github.com/mdlayher/netlink"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// xfrm attribute types
+const (
+	IFLA_XFRM_UNSPEC uint16 = iota
+	IFLA_XFRM_LINK
+	IFLA_XFRM_IF_ID
+	IFLA_XFRM_COLLECT_METADATA
+)
+
+// Xfrm encapsulates information about xfrm interfaces, which carry the
+// traffic of ipsec policies bound to the same interface id for route based
+// vpns.
+type Xfrm struct {
+	Link            uint32 // interface index
+	IfId            uint32
+	CollectMetadata bool
+}
+
+// Marshal turns an xfrm into a binary rtnetlink set of attributes.
+func (x *Xfrm) Marshal(ctx *Context) ([]byte, error) {
+
+	ae := netlink.NewAttributeEncoder()
+	ae.Do(unix.IFLA_LINKINFO, func() ([]byte, error) {
+
+		ae1 := netlink.NewAttributeEncoder()
+		ae1.Bytes(IFLA_INFO_KIND, []byte("xfrm"))
+		ae1.Do(IFLA_INFO_DATA, func() ([]byte, error) {
+
+			ae2 := netlink.NewAttributeEncoder()
+
+			// in collect metadata mode the interface id comes from the
+			// per-packet metadata
+			if x.CollectMetadata {
+				ae2.Bytes(IFLA_XFRM_COLLECT_METADATA, []byte{})
+			} else {
+				ae2.Uint32(IFLA_XFRM_IF_ID, x.IfId)
+			}
+
+			if x.Link != 0 {
+				ae2.Uint32(IFLA_XFRM_LINK, x.Link)
+			}
+
+			return ae2.Encode()
+
+		})
+
+		return ae1.Encode()
+
+	})
+	attrbuf, err := ae.Encode()
+	if err != nil {
+		log.WithError(err).Error("failed to encode xfrm attributes")
+		return nil, err
+	}
+
+	return attrbuf, nil
+
+}
+
+// Unmarshal reads an xfrm from a binary set of attributes.
+func (x *Xfrm) Unmarshal(ctx *Context, buf []byte) error {
+
+	ad, err := netlink.NewAttributeDecoder(buf)
+	if err != nil {
+		log.WithError(err).Error("failed to create xfrm attribute decoder")
+		return err
+	}
+
+	for ad.Next() {
+		switch ad.Type() {
+
+		case IFLA_XFRM_LINK:
+			x.Link = ad.Uint32()
+
+		case IFLA_XFRM_IF_ID:
+			x.IfId = ad.Uint32()
+
+		case IFLA_XFRM_COLLECT_METADATA:
+			x.CollectMetadata = true
+
+		}
+	}
+
+	return nil
+
+}
+
+// Resolve handle attributes
+func (x *Xfrm) Resolve(ctx *Context) error {
+
+	return nil
+
+}